@@ -0,0 +1,45 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// SetContentLocation sets the Content-Location header of the response to
+// loc, the URL of the specific representation returned, e.g. the one chosen
+// by content negotiation.
+//
+// loc must be either a reference relative to the request URL or an absolute
+// http or https URL, without user information or fragment. It is written in
+// its normalized, escaped form.
+func SetContentLocation(w ResponseWriter, loc string) error {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return fmt.Errorf("invalid Content-Location: %v", err)
+	}
+	if u.IsAbs() && ((u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		return errors.New("Content-Location is not an http or https URL")
+	}
+	if u.Opaque != "" {
+		return errors.New("Content-Location is an opaque URL")
+	}
+	if u.User != nil || u.Fragment != "" {
+		return errors.New("Content-Location contains user information or a fragment")
+	}
+	return w.Header().Set("Content-Location", u.String())
+}
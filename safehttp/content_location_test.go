@@ -0,0 +1,85 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetContentLocationNegotiated(t *testing.T) {
+	representations := map[string]string{
+		"application/json": "/orders/1.json",
+		"text/html":        "/orders/1.html",
+	}
+	req := httptest.NewRequest("GET", "/orders/1", nil)
+	req.Header.Set("Accept", "text/html;q=0.5, application/json")
+	ir := newIncomingRequest(req)
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+
+	mt := Negotiate(rw, &ir, "text/html", "application/json")
+	if err := SetContentLocation(rw, representations[mt]); err != nil {
+		t.Fatalf("SetContentLocation() got err: %v", err)
+	}
+	rw.NoContent()
+
+	if got, want := rec.Header().Get("Content-Location"), "/orders/1.json"; got != want {
+		t.Errorf(`rec.Header().Get("Content-Location") got: %q want: %q`, got, want)
+	}
+}
+
+func TestSetContentLocation(t *testing.T) {
+	var tests = []struct {
+		name    string
+		loc     string
+		want    string
+		wantErr bool
+	}{
+		{name: "Relative", loc: "1.json", want: "1.json"},
+		{name: "Absolute", loc: "https://example.com/orders/1.json", want: "https://example.com/orders/1.json"},
+		{name: "Escaped", loc: "/orders/a b.json", want: "/orders/a%20b.json"},
+		{name: "JavaScript", loc: "javascript:alert(1)", wantErr: true},
+		{name: "NoHost", loc: "https:/orders/1.json", wantErr: true},
+		{name: "UserInfo", loc: "https://user@example.com/", wantErr: true},
+		{name: "Fragment", loc: "/orders/1.json#top", wantErr: true},
+		{name: "Invalid", loc: "/orders/%zz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ir := newIncomingRequest(httptest.NewRequest("GET", "/orders/1", nil))
+			rec := httptest.NewRecorder()
+			rw := newResponseWriter(nil, rec, &ir, nil)
+
+			err := SetContentLocation(rw, tt.loc)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("SetContentLocation(%q) got: nil want: error", tt.loc)
+				}
+				if got := rec.Header().Get("Content-Location"); got != "" {
+					t.Errorf(`rec.Header().Get("Content-Location") got: %q want: ""`, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetContentLocation(%q) got err: %v", tt.loc, err)
+			}
+			if got := rec.Header().Get("Content-Location"); got != tt.want {
+				t.Errorf(`rec.Header().Get("Content-Location") got: %q want: %q`, got, tt.want)
+			}
+		})
+	}
+}
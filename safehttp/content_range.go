@@ -0,0 +1,96 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidContentRange is returned when parsing a malformed Content-Range
+// header.
+var ErrInvalidContentRange = errors.New("safehttp: invalid Content-Range")
+
+// ContentRange is the byte range of a resource carried by the body of a
+// request, e.g. a chunk of a resumable upload sent with PUT, as described by
+// its Content-Range header.
+type ContentRange struct {
+	// First and Last are the offsets of the first and the last byte of the
+	// range, inclusive.
+	First, Last int64
+	// Size is the complete length of the resource, or -1 if unknown.
+	Size int64
+}
+
+// Length returns the number of bytes in the range.
+func (cr ContentRange) Length() int64 {
+	return cr.Last - cr.First + 1
+}
+
+// ParseContentRange parses the value of a Content-Range header of a request,
+// in the "bytes first-last/size" form, where size may be "*" if unknown, as
+// defined in RFC 7233, Section 4.2. The unsatisfied range form "bytes */size"
+// is only valid in responses and is rejected. It returns
+// ErrInvalidContentRange if the value is malformed or if the range is not
+// within the size.
+func ParseContentRange(s string) (ContentRange, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "bytes ") {
+		return ContentRange{}, ErrInvalidContentRange
+	}
+	s = strings.TrimLeft(s[len("bytes "):], " ")
+	slash := strings.IndexByte(s, '/')
+	dash := strings.IndexByte(s, '-')
+	if slash < 0 || dash < 0 || dash > slash {
+		return ContentRange{}, ErrInvalidContentRange
+	}
+	first, ok1 := parseOffset(s[:dash])
+	last, ok2 := parseOffset(s[dash+1 : slash])
+	if !ok1 || !ok2 || first > last {
+		return ContentRange{}, ErrInvalidContentRange
+	}
+	cr := ContentRange{First: first, Last: last, Size: -1}
+	if size := s[slash+1:]; size != "*" {
+		n, ok := parseOffset(size)
+		if !ok || last >= n {
+			return ContentRange{}, ErrInvalidContentRange
+		}
+		cr.Size = n
+	}
+	return cr, nil
+}
+
+// parseOffset parses a non-negative decimal integer, without sign or
+// surrounding whitespace.
+func parseOffset(s string) (int64, bool) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+// ContentRange parses the Content-Range header of the request. It reports
+// whether the header is present and returns ErrInvalidContentRange if it is
+// malformed.
+func (r *IncomingRequest) ContentRange() (ContentRange, bool, error) {
+	v := r.Header.Get("Content-Range")
+	if v == "" {
+		return ContentRange{}, false, nil
+	}
+	cr, err := ParseContentRange(v)
+	return cr, true, err
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseContentRange(t *testing.T) {
+	var tests = []struct {
+		name    string
+		value   string
+		want    ContentRange
+		wantErr bool
+	}{
+		{
+			name:  "Valid",
+			value: "bytes 0-499/1234",
+			want:  ContentRange{First: 0, Last: 499, Size: 1234},
+		},
+		{
+			name:  "LastChunk",
+			value: "bytes 1000-1233/1234",
+			want:  ContentRange{First: 1000, Last: 1233, Size: 1234},
+		},
+		{
+			name:  "UnknownSize",
+			value: "bytes 500-999/*",
+			want:  ContentRange{First: 500, Last: 999, Size: -1},
+		},
+		{name: "Empty", value: "", wantErr: true},
+		{name: "OtherUnit", value: "items 0-1/2", wantErr: true},
+		{name: "Unsatisfied", value: "bytes */1234", wantErr: true},
+		{name: "Reversed", value: "bytes 500-499/1234", wantErr: true},
+		{name: "BeyondSize", value: "bytes 0-1234/1234", wantErr: true},
+		{name: "Negative", value: "bytes -1-499/1234", wantErr: true},
+		{name: "Sign", value: "bytes +0-499/1234", wantErr: true},
+		{name: "MissingSize", value: "bytes 0-499", wantErr: true},
+		{name: "MissingLast", value: "bytes 0-/1234", wantErr: true},
+		{name: "Overflow", value: "bytes 0-99999999999999999999/*", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseContentRange(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidContentRange) {
+					t.Errorf("ParseContentRange(%q) got err: %v want: ErrInvalidContentRange", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseContentRange(%q) got err: %v want: nil", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseContentRange(%q) got: %+v want: %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ParseHTTPDate parses a date in one of the three formats allowed in HTTP
+// headers like If-Modified-Since, as defined in RFC 7231, Section 7.1.1.1:
+// the preferred IMF-fixdate (RFC 1123) and the obsolete RFC 850 and ANSI C
+// asctime formats. Surrounding whitespace is ignored. The date is returned in
+// UTC.
+func ParseHTTPDate(s string) (time.Time, error) {
+	t, err := http.ParseTime(strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHTTPDate(t *testing.T) {
+	want := time.Date(1994, time.November, 6, 8, 49, 37, 0, time.UTC)
+	var tests = []struct {
+		name  string
+		input string
+	}{
+		{name: "RFC1123", input: "Sun, 06 Nov 1994 08:49:37 GMT"},
+		{name: "RFC850", input: "Sunday, 06-Nov-94 08:49:37 GMT"},
+		{name: "ANSIC", input: "Sun Nov  6 08:49:37 1994"},
+		{name: "Whitespace", input: "  Sun, 06 Nov 1994 08:49:37 GMT "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHTTPDate(tt.input)
+			if err != nil {
+				t.Fatalf("ParseHTTPDate(%q) got err: %v", tt.input, err)
+			}
+			if !got.Equal(want) || got.Location() != time.UTC {
+				t.Errorf("ParseHTTPDate(%q) got: %v want: %v", tt.input, got, want)
+			}
+		})
+	}
+}
+
+func TestParseHTTPDateInvalid(t *testing.T) {
+	for _, input := range []string{"", "yesterday", "1994-11-06T08:49:37Z"} {
+		if _, err := ParseHTTPDate(input); err == nil {
+			t.Errorf("ParseHTTPDate(%q) got: nil want: error", input)
+		}
+	}
+}
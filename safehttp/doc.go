@@ -0,0 +1,34 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package safehttp provides a framework for building secure-by-default web
+// applications. Handlers registered on a ServeMux write their responses
+// through a ResponseWriter, and the interceptors installed on the ServeMux
+// run for every request, e.g. to reject it or to add security headers to the
+// response.
+//
+// The Before phase of interceptors runs in the order they were installed and
+// the Commit phase in the reverse order, unless they have priorities (see
+// WithPriority). Since Commit runs for every enabled interceptor, even when a
+// Before phase rejected the request, the order matters in two cases:
+//
+// Interceptors inspecting or fixing up the headers and cookies set by the
+// others in their Commit phase, e.g. samesite, securecookie, tlsheaders,
+// requiredheaders and compress.Interceptor, must commit last. Install them
+// first, or give them a negative priority.
+//
+// Interceptors recording every request in their Before phase, e.g. auditlog,
+// must also be installed first, so that they see the requests rejected by
+// the Before phase of the others.
+package safehttp
@@ -0,0 +1,65 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import "net/http"
+
+// ErrorHandler writes the error responses of a ServeMux, written by handlers
+// and interceptors with ResponseWriter.ClientError or ServerError, e.g. to
+// render branded error pages or JSON error envelopes.
+//
+// The ResponseWriter passed to HandleError can write any kind of response,
+// but its status code is always code, unless it is replaced by another error
+// status code. The commit phase of the installed interceptors runs as usual.
+// If HandleError doesn't write a response, the default plain text response
+// is written.
+type ErrorHandler interface {
+	HandleError(w ResponseWriter, r *IncomingRequest, code StatusCode) Result
+}
+
+var _ ErrorHandler = ErrorPage{}
+
+// ErrorHandlerFunc is a function implementing ErrorHandler.
+type ErrorHandlerFunc func(w ResponseWriter, r *IncomingRequest, code StatusCode) Result
+
+// HandleError calls f(w, r, code).
+func (f ErrorHandlerFunc) HandleError(w ResponseWriter, r *IncomingRequest, code StatusCode) Result {
+	return f(w, r, code)
+}
+
+// errorStatusWriter replaces non-error status codes written by an
+// ErrorHandler with the status code of the error.
+type errorStatusWriter struct {
+	http.ResponseWriter
+	code        StatusCode
+	wroteHeader bool
+}
+
+func (ew *errorStatusWriter) WriteHeader(code int) {
+	if ew.wroteHeader {
+		return
+	}
+	ew.wroteHeader = true
+	if code < 400 {
+		code = int(ew.code)
+	}
+	ew.ResponseWriter.WriteHeader(code)
+}
+
+func (ew *errorStatusWriter) Write(b []byte) (int, error) {
+	// Implicit 200 OK status codes must be replaced as well.
+	ew.WriteHeader(int(ew.code))
+	return ew.ResponseWriter.Write(b)
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+type statusRecordingInterceptor struct {
+	code *StatusCode
+}
+
+func (it statusRecordingInterceptor) Before(w ResponseWriter, r *IncomingRequest) Result {
+	return Result{}
+}
+
+func (it statusRecordingInterceptor) Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result {
+	*it.code = w.StatusCode()
+	w.Header().Set("X-Frame-Options", "DENY")
+	return Result{}
+}
+
+func TestErrorHandler(t *testing.T) {
+	var tests = []struct {
+		name         string
+		errorHandler ErrorHandler
+		wantCode     int
+		wantBody     string
+	}{
+		{
+			name:     "Default",
+			wantCode: http.StatusNotFound,
+			wantBody: "Not Found\n",
+		},
+		{
+			name:         "ErrorPage",
+			errorHandler: ErrorPage{},
+			wantCode:     http.StatusNotFound,
+			wantBody:     `{"code":404,"message":"Not Found"}` + "\n",
+		},
+		{
+			name: "Body",
+			errorHandler: ErrorHandlerFunc(func(w ResponseWriter, r *IncomingRequest, code StatusCode) Result {
+				return w.writeBody("text/plain; charset=utf-8", []byte("Nothing here"))
+			}),
+			wantCode: http.StatusNotFound,
+			wantBody: "Nothing here",
+		},
+		{
+			name: "NoContent",
+			errorHandler: ErrorHandlerFunc(func(w ResponseWriter, r *IncomingRequest, code StatusCode) Result {
+				return w.NoContent()
+			}),
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "OtherError",
+			errorHandler: ErrorHandlerFunc(func(w ResponseWriter, r *IncomingRequest, code StatusCode) Result {
+				return w.ClientError(Status403Forbidden)
+			}),
+			wantCode: http.StatusForbidden,
+			wantBody: "Forbidden\n",
+		},
+		{
+			name: "NoResponse",
+			errorHandler: ErrorHandlerFunc(func(w ResponseWriter, r *IncomingRequest, code StatusCode) Result {
+				return Result{}
+			}),
+			wantCode: http.StatusNotFound,
+			wantBody: "Not Found\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var committed StatusCode
+			m := NewServeMux(nil)
+			m.SetErrorHandler(tt.errorHandler)
+			m.Install(statusRecordingInterceptor{code: &committed})
+			m.Handle("/", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.ClientError(Status404NotFound)
+			})
+
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := int(committed); got != tt.wantCode {
+				t.Errorf("w.StatusCode() in Commit got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+			if got, want := rec.Header().Get("X-Frame-Options"), "DENY"; got != want {
+				t.Errorf(`rec.Header().Get("X-Frame-Options") got: %q want: %q`, got, want)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerPanic(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	m := NewServeMux(nil)
+	m.SetErrorHandler(ErrorHandlerFunc(func(w ResponseWriter, r *IncomingRequest, code StatusCode) Result {
+		panic("broken error page")
+	}))
+	m.Handle("/", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.ClientError(Status404NotFound)
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+}
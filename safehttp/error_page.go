@@ -0,0 +1,81 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// ErrorData describes an error response. It is the data passed to the
+// template of an ErrorPage and the body of JSON error responses.
+type ErrorData struct {
+	Code    StatusCode `json:"code"`
+	Message string     `json:"message"`
+}
+
+// ErrorPage writes error responses in the format preferred by the client:
+// either a JSON object or an HTML page rendered from a template.
+type ErrorPage struct {
+	// Template renders HTML error pages. It is executed with an ErrorData
+	// by the Dispatcher. If nil, errors are always written as JSON.
+	Template Template
+}
+
+// Write writes an error response with the given status code. If the Accept
+// header of the request prefers text/html over application/json, the HTML
+// error page is rendered. Otherwise, or if the template fails, the ErrorData
+// is written as JSON. The commit phase of the installed interceptors runs
+// first. When the format is negotiated, i.e. if the Template is set, Accept
+// is added to the Vary header.
+func (p ErrorPage) Write(w ResponseWriter, r *IncomingRequest, code StatusCode) Result {
+	if code < 400 || code >= 600 {
+		panic("not an error status code")
+	}
+	data := ErrorData{Code: code, Message: http.StatusText(int(code))}
+	// ErrorData can always be encoded.
+	body, _ := json.Marshal(data)
+	var html *bufferedResponse
+	if p.Template != nil && negotiateMediaType(r.Header.Values("Accept"), "application/json", "text/html") == "text/html" {
+		html = newBufferedResponse()
+		if err := w.d.ExecuteTemplate(html, p.Template, data); err != nil {
+			log.Printf("safehttp: executing error page template: %v", err)
+			html = nil
+		}
+	}
+	if !w.commitError(code, data) {
+		return Result{}
+	}
+	h := w.rw.Header()
+	h.Set("X-Content-Type-Options", "nosniff")
+	if p.Template != nil {
+		addVary(h, "Accept")
+	}
+	if html != nil {
+		h.Set("Content-Type", "text/html; charset=utf-8")
+		w.rw.WriteHeader(int(code))
+		w.rw.Write(html.body.Bytes())
+		return Result{}
+	}
+	w.writeJSON(code, "application/json; charset=utf-8", body)
+	return Result{}
+}
+
+// HandleError writes the error response with Write, so that an ErrorPage can
+// be used as the ErrorHandler of a ServeMux.
+func (p ErrorPage) HandleError(w ResponseWriter, r *IncomingRequest, code StatusCode) Result {
+	return p.Write(w, r, code)
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/safehtml/template"
+)
+
+type templateDispatcher struct{}
+
+func (templateDispatcher) Write(rw http.ResponseWriter, resp Response) error {
+	panic("not implemented")
+}
+
+func (templateDispatcher) ExecuteTemplate(rw http.ResponseWriter, t Template, data interface{}) error {
+	return t.Execute(rw, data)
+}
+
+func TestErrorPage(t *testing.T) {
+	p := ErrorPage{
+		Template: template.Must(template.New("error").Parse("<h1>{{.Code}} {{.Message}}</h1>")),
+	}
+
+	var tests = []struct {
+		name            string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "JSON",
+			accept:          "application/json",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"code":404,"message":"Not Found"}` + "\n",
+		},
+		{
+			name:            "HTML",
+			accept:          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        "<h1>404 Not Found</h1>",
+		},
+		{
+			name:            "NoAccept",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"code":404,"message":"Not Found"}` + "\n",
+		},
+		{
+			name:            "HTMLRejected",
+			accept:          "text/html;q=0, */*",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"code":404,"message":"Not Found"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/missing", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			ir := newIncomingRequest(req)
+			rec := httptest.NewRecorder()
+			rw := newResponseWriter(templateDispatcher{}, rec, &ir, nil)
+
+			p.Write(rw, &ir, Status404NotFound)
+
+			if got, want := rec.Code, http.StatusNotFound; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, tt.wantContentType)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+			if got, want := rec.Header().Get("Vary"), "Accept"; got != want {
+				t.Errorf(`rec.Header().Get("Vary") got: %q want: %q`, got, want)
+			}
+		})
+	}
+}
+
+func TestErrorPageTemplateError(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	p := ErrorPage{
+		Template: template.Must(template.New("error").Parse("<h1>{{.Missing}}</h1>")),
+	}
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set("Accept", "text/html")
+	ir := newIncomingRequest(req)
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(templateDispatcher{}, rec, &ir, nil)
+
+	p.Write(rw, &ir, Status404NotFound)
+
+	if got, want := rec.Code, http.StatusNotFound; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Content-Type"), "application/json; charset=utf-8"; got != want {
+		t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, want)
+	}
+	if got, want := rec.Body.String(), `{"code":404,"message":"Not Found"}`+"\n"; got != want {
+		t.Errorf("rec.Body got: %q want: %q", got, want)
+	}
+}
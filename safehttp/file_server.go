@@ -0,0 +1,121 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"log"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileServerConfig configures the handler returned by FileServer.
+type FileServerConfig struct {
+	// Root is the directory the files are served from.
+	Root string
+	// Prefix is stripped from the path of the request before resolving it
+	// under Root. It is usually the pattern the handler is registered for,
+	// e.g. "/static/".
+	Prefix string
+	// Index is the name of the file served for requests for a directory,
+	// e.g. "index.html". If empty, such requests are answered with 404 Not
+	// Found. Directory listings are never served.
+	Index string
+}
+
+// FileServer returns a handler serving the regular files under the root
+// directory through the ResponseWriter, so that the installed interceptors
+// apply to them, unlike with http.FileServer.
+//
+// Requests with a path containing a ".." segment are rejected with 400 Bad
+// Request. Files that resolve outside of the root directory, through
+// symbolic links, are not served: they are answered with 404 Not Found, like
+// missing files and files that are not regular. The Content-Type is
+// determined by the extension of the file, falling back to
+// application/octet-stream, and is never sniffed from the content.
+//
+// The handler should be registered for GET requests, which makes it serve
+// HEAD requests too.
+func FileServer(cfg FileServerConfig) HandleFunc {
+	return func(w ResponseWriter, r *IncomingRequest) Result {
+		p := r.URL().Path
+		if !strings.HasPrefix(p, cfg.Prefix) {
+			return w.ClientError(Status404NotFound)
+		}
+		p = p[len(cfg.Prefix):]
+		if containsDotDot(p) || strings.ContainsAny(p, "\\\x00") {
+			return w.ClientError(Status400BadRequest)
+		}
+		root, err := filepath.EvalSymlinks(cfg.Root)
+		if err != nil {
+			log.Printf("safehttp: resolving file server root: %v", err)
+			return w.ServerError(Status500InternalServerError, nil)
+		}
+		name, ok := resolveFile(root, filepath.Join(root, filepath.FromSlash(path.Clean("/"+p))))
+		if !ok {
+			return w.ClientError(Status404NotFound)
+		}
+		fi, err := os.Stat(name)
+		if err == nil && fi.IsDir() {
+			if cfg.Index == "" {
+				return w.ClientError(Status404NotFound)
+			}
+			name, ok = resolveFile(root, filepath.Join(name, cfg.Index))
+			if !ok {
+				return w.ClientError(Status404NotFound)
+			}
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return w.ClientError(Status404NotFound)
+		}
+		defer f.Close()
+		fi, err = f.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return w.ClientError(Status404NotFound)
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		return w.writeContent(contentType, fi.Size(), f)
+	}
+}
+
+// resolveFile resolves the symbolic links in name and reports whether the
+// result is still under root, which must not contain symbolic links itself.
+func resolveFile(root, name string) (string, bool) {
+	resolved, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return resolved, true
+}
+
+// containsDotDot reports whether p has a ".." segment.
+func containsDotDot(p string) bool {
+	for _, s := range strings.Split(p, "/") {
+		if s == ".." {
+			return true
+		}
+	}
+	return false
+}
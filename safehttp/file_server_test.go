@@ -0,0 +1,172 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileserver")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() got err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "root")
+	files := map[string]string{
+		"secret.txt":             "secret",
+		"root/hello.txt":         "hello",
+		"root/style.css":         "body {}",
+		"root/blob":              "<html>",
+		"root/docs/index.html":   "<p>docs</p>",
+		"root/images/.gitignore": "",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("os.MkdirAll() got err: %v", err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("ioutil.WriteFile() got err: %v", err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "escape.txt")); err != nil {
+		t.Fatalf("os.Symlink() got err: %v", err)
+	}
+	if err := os.Symlink(dir, filepath.Join(root, "parent")); err != nil {
+		t.Fatalf("os.Symlink() got err: %v", err)
+	}
+	if err := os.Symlink("hello.txt", filepath.Join(root, "alias.txt")); err != nil {
+		t.Fatalf("os.Symlink() got err: %v", err)
+	}
+
+	var tests = []struct {
+		name            string
+		index           string
+		path            string
+		wantCode        int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "Text",
+			path:            "/static/hello.txt",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "hello",
+		},
+		{
+			name:            "CSS",
+			path:            "/static/style.css",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/css; charset=utf-8",
+			wantBody:        "body {}",
+		},
+		{
+			name:            "NoExtension",
+			path:            "/static/blob",
+			wantCode:        http.StatusOK,
+			wantContentType: "application/octet-stream",
+			wantBody:        "<html>",
+		},
+		{
+			name:            "SymlinkInsideRoot",
+			path:            "/static/alias.txt",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "hello",
+		},
+		{
+			name:     "Missing",
+			path:     "/static/missing.txt",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "DotDot",
+			path:     "/static/../secret.txt",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "EscapedDotDot",
+			path:     "/static/%2e%2e/secret.txt",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Backslash",
+			path:     `/static/..\secret.txt`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "SymlinkEscape",
+			path:     "/static/escape.txt",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "SymlinkedDirectoryEscape",
+			path:     "/static/parent/secret.txt",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "DirectoryWithoutIndex",
+			path:     "/static/docs/",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:            "DirectoryIndex",
+			index:           "index.html",
+			path:            "/static/docs/",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        "<p>docs</p>",
+		},
+		{
+			name:     "DirectoryMissingIndex",
+			index:    "index.html",
+			path:     "/static/images/",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Machinery doesn't clean the path of the request, unlike ServeMux.
+			m := NewMachinery(FileServer(FileServerConfig{Root: root, Prefix: "/static/", Index: tt.index}), nil)
+
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, httptest.NewRequest("GET", tt.path, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, tt.wantContentType)
+			}
+			if got, want := rec.Header().Get("X-Content-Type-Options"), "nosniff"; got != want {
+				t.Errorf(`rec.Header().Get("X-Content-Type-Options") got: %q want: %q`, got, want)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+		})
+	}
+}
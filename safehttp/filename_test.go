@@ -0,0 +1,56 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import "testing"
+
+func TestSanitizeFilename(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Plain", input: "report.pdf", want: "report.pdf"},
+		{name: "Traversal", input: "../../etc/passwd", want: "passwd"},
+		{name: "WindowsPath", input: `C:\Users\alice\photo.jpg`, want: "photo.jpg"},
+		{name: "ControlChars", input: "a\x00b\nc.txt", want: "abc.txt"},
+		{name: "ReservedChars", input: `what?<is>"this".txt`, want: "whatisthis.txt"},
+		{name: "HiddenFile", input: ".htaccess", want: "htaccess"},
+		{name: "TrailingDotsAndSpaces", input: "notes.txt. . ", want: "notes.txt"},
+		{name: "DeviceName", input: "CON", want: "_CON"},
+		{name: "DeviceNameWithExtension", input: "lpt1.txt", want: "_lpt1.txt"},
+		{name: "NotDeviceName", input: "console.log", want: "console.log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeFilename(tt.input)
+			if err != nil {
+				t.Fatalf("SanitizeFilename(%q) got err: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("SanitizeFilename(%q) got: %q want: %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilenameInvalid(t *testing.T) {
+	for _, input := range []string{"", "..", "../", "dir/", " . "} {
+		if got, err := SanitizeFilename(input); err != ErrInvalidFilename {
+			t.Errorf("SanitizeFilename(%q) got: %q, %v want: ErrInvalidFilename", input, got, err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// ErrInvalidFormValue is returned by IncomingRequest.DecodeForm and
+// IncomingRequest.DecodeMultipartForm when a form value can't be converted
+// to the type of the field it is decoded into.
+var ErrInvalidFormValue = errors.New("safehttp: invalid form value")
+
+// DecodeForm parses the body of the request as an URL-encoded form, like
+// PostForm, and stores the form values in the struct pointed to by dst.
+//
+// Each exported field is filled with the values of the form field named by
+// its "safehttp" tag, or by the field name if it has no tag. Fields tagged
+// with "-" are ignored. Fields can be strings, bools, signed or unsigned
+// integers, or slices of those. Non-slice fields take the first value of the
+// form field. Fields missing from the form are left untouched. If a value
+// can't be converted, an error wrapping ErrInvalidFormValue and naming the
+// field is returned.
+func (r *IncomingRequest) DecodeForm(dst interface{}) error {
+	if err := r.req.ParseForm(); err != nil {
+		return err
+	}
+	return decodeForm(r.req.PostForm, dst)
+}
+
+// DecodeMultipartForm is like DecodeForm, for multipart/form-data bodies. Up
+// to maxMemory bytes of the uploaded files are stored in memory, the rest is
+// stored on disk. Files are not decoded into dst. The body is only parsed
+// once, subsequent calls reuse the parsed form.
+func (r *IncomingRequest) DecodeMultipartForm(dst interface{}, maxMemory int64) error {
+	if err := r.req.ParseMultipartForm(maxMemory); err != nil {
+		return err
+	}
+	return decodeForm(r.req.MultipartForm.Value, dst)
+}
+
+func decodeForm(form url.Values, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("safehttp: form destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("safehttp"); ok {
+			name = tag
+		}
+		if name == "-" {
+			continue
+		}
+		values, ok := form[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if err := setField(v.Field(i), values); err != nil {
+			return fmt.Errorf("form field %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setField converts the values to the type of the field and stores them in
+// it.
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() != reflect.Slice {
+		return setValue(field, values[0])
+	}
+	s := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := setValue(s.Index(i), value); err != nil {
+			return err
+		}
+	}
+	field.Set(s)
+	return nil
+}
+
+func setValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a bool", ErrInvalidFormValue, value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a valid %s", ErrInvalidFormValue, value, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a valid %s", ErrInvalidFormValue, value, v.Type())
+		}
+		v.SetUint(n)
+	default:
+		return fmt.Errorf("safehttp: unsupported field type %s", v.Type())
+	}
+	return nil
+}
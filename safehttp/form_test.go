@@ -0,0 +1,203 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type signupForm struct {
+	Name       string   `safehttp:"name"`
+	Age        int      `safehttp:"age"`
+	Newsletter bool     `safehttp:"newsletter"`
+	Tags       []string `safehttp:"tag"`
+	Scores     []uint8  `safehttp:"score"`
+	Referrer   string
+	Ignored    string `safehttp:"-"`
+}
+
+func newFormRequest(form url.Values) *IncomingRequest {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	ir := newIncomingRequest(req)
+	return &ir
+}
+
+func TestDecodeForm(t *testing.T) {
+	var tests = []struct {
+		name string
+		form url.Values
+		want signupForm
+	}{
+		{
+			name: "AllFields",
+			form: url.Values{
+				"name":       {"alice"},
+				"age":        {"42"},
+				"newsletter": {"true"},
+				"tag":        {"a", "b"},
+				"score":      {"1", "255"},
+				"Referrer":   {"search"},
+				"Ignored":    {"x"},
+				"-":          {"x"},
+			},
+			want: signupForm{
+				Name:       "alice",
+				Age:        42,
+				Newsletter: true,
+				Tags:       []string{"a", "b"},
+				Scores:     []uint8{1, 255},
+				Referrer:   "search",
+			},
+		},
+		{
+			name: "MissingFields",
+			form: url.Values{"name": {"bob"}},
+			want: signupForm{Name: "bob"},
+		},
+		{
+			name: "FirstValue",
+			form: url.Values{"name": {"bob", "eve"}},
+			want: signupForm{Name: "bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got signupForm
+			if err := newFormRequest(tt.form).DecodeForm(&got); err != nil {
+				t.Fatalf("DecodeForm: got err %v want nil", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("DecodeForm mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeFormInvalidValue(t *testing.T) {
+	var tests = []struct {
+		name      string
+		form      url.Values
+		wantField string
+	}{
+		{
+			name:      "Int",
+			form:      url.Values{"age": {"forty"}},
+			wantField: `"age"`,
+		},
+		{
+			name:      "Bool",
+			form:      url.Values{"newsletter": {"maybe"}},
+			wantField: `"newsletter"`,
+		},
+		{
+			name:      "UintOverflow",
+			form:      url.Values{"score": {"1", "256"}},
+			wantField: `"score"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got signupForm
+			err := newFormRequest(tt.form).DecodeForm(&got)
+			if !errors.Is(err, ErrInvalidFormValue) {
+				t.Fatalf("DecodeForm: got err %v want ErrInvalidFormValue", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantField) {
+				t.Errorf("DecodeForm: got err %q want it to name %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestDecodeFormInvalidDestination(t *testing.T) {
+	var tests = []struct {
+		name string
+		dst  interface{}
+	}{
+		{
+			name: "NotPointer",
+			dst:  signupForm{},
+		},
+		{
+			name: "NilPointer",
+			dst:  (*signupForm)(nil),
+		},
+		{
+			name: "UnsupportedField",
+			dst: &struct {
+				Age float64 `safehttp:"age"`
+			}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newFormRequest(url.Values{"age": {"1"}}).DecodeForm(tt.dst)
+			if err == nil {
+				t.Error("DecodeForm: got nil err want error")
+			}
+			if errors.Is(err, ErrInvalidFormValue) {
+				t.Errorf("DecodeForm: got err %v want it not to be ErrInvalidFormValue", err)
+			}
+		})
+	}
+}
+
+func TestDecodeFormAlreadyParsed(t *testing.T) {
+	r := newFormRequest(url.Values{"name": {"alice"}})
+	if _, err := r.PostForm(); err != nil {
+		t.Fatalf("PostForm: got err %v want nil", err)
+	}
+
+	var got signupForm
+	if err := r.DecodeForm(&got); err != nil {
+		t.Fatalf("DecodeForm: got err %v want nil", err)
+	}
+	if want := "alice"; got.Name != want {
+		t.Errorf("got.Name got: %q want: %q", got.Name, want)
+	}
+}
+
+func TestDecodeMultipartForm(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("name", "alice")
+	mw.WriteField("tag", "a")
+	mw.WriteField("tag", "b")
+	mw.Close()
+	req := httptest.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	r := newIncomingRequest(req)
+
+	var got signupForm
+	if err := r.DecodeMultipartForm(&got, 1<<20); err != nil {
+		t.Fatalf("DecodeMultipartForm: got err %v want nil", err)
+	}
+	want := signupForm{Name: "alice", Tags: []string{"a", "b"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DecodeMultipartForm mismatch (-want +got):\n%s", diff)
+	}
+}
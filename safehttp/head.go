@@ -0,0 +1,65 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// headWriter discards the body of responses to HEAD requests, keeping track
+// of its length. The status code is only sent once the handler returns, so
+// that the headers can still be fixed up.
+type headWriter struct {
+	http.ResponseWriter
+	code int
+	n    int
+}
+
+func (hw *headWriter) WriteHeader(code int) {
+	if hw.code == 0 {
+		hw.code = code
+	}
+}
+
+func (hw *headWriter) Write(b []byte) (int, error) {
+	hw.WriteHeader(http.StatusOK)
+	hw.n += len(b)
+	return len(b), nil
+}
+
+// finish sends the response headers. If the handler wrote a body, explicit
+// tells whether it is a HEAD handler, which should never write one, or a GET
+// handler serving the HEAD request, whose body length is used as
+// Content-Length unless the handler already described the body.
+func (hw *headWriter) finish(pattern string, explicit bool) {
+	if hw.code == 0 {
+		hw.code = http.StatusOK
+	}
+	h := hw.Header()
+	if hw.n > 0 && explicit {
+		log.Printf("safehttp: HEAD handler for %q wrote a body of %d bytes", pattern, hw.n)
+		for _, name := range []string{"Content-Type", "Content-Encoding", "Content-Length"} {
+			h.Del(name)
+		}
+		hw.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if hw.n > 0 && h.Get("Content-Length") == "" && h.Get("Transfer-Encoding") == "" {
+		h.Set("Content-Length", strconv.Itoa(hw.n))
+	}
+	hw.ResponseWriter.WriteHeader(hw.code)
+}
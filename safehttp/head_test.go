@@ -0,0 +1,87 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestServeMuxHead(t *testing.T) {
+	var tests = []struct {
+		name              string
+		headHandler       HandleFunc
+		wantCode          int
+		wantContentLength string
+		wantContentType   string
+	}{
+		{
+			name:              "ServedByGet",
+			wantCode:          http.StatusOK,
+			wantContentLength: "5",
+			wantContentType:   "text/plain; charset=utf-8",
+		},
+		{
+			name: "HeadHandler",
+			headHandler: func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.NoContent()
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name: "HeadHandlerWritingBody",
+			headHandler: func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.writeBody("text/plain; charset=utf-8", []byte("oops"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			m := NewServeMux(nil)
+			m.Handle("/hello", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.writeBody("text/plain; charset=utf-8", []byte("hello"))
+			})
+			if tt.headHandler != nil {
+				m.Handle("/hello", "HEAD", tt.headHandler)
+			}
+
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest("HEAD", "/hello", nil))
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Header().Get("Content-Length"); got != tt.wantContentLength {
+				t.Errorf(`rec.Header().Get("Content-Length") got: %q want: %q`, got, tt.wantContentLength)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, tt.wantContentType)
+			}
+			if got := rec.Body.String(); got != "" {
+				t.Errorf("rec.Body got: %q want: empty", got)
+			}
+		})
+	}
+}
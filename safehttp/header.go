@@ -16,32 +16,65 @@ package safehttp
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/textproto"
+	"sort"
+	"strings"
 )
 
 // Header represents the key-value pairs in an HTTP header.
 // The keys will be in canonical form, as returned by
 // textproto.CanonicalMIMEHeaderKey.
 type Header struct {
-	wrapped   http.Header
-	immutable map[string]bool
+	wrapped      http.Header
+	immutable    map[string]bool
+	cookiePolicy CookiePolicy
 }
 
+// CookiePolicy configures the security attributes required of the cookies
+// set through Header.SetCookie.
+type CookiePolicy struct {
+	// RequireSameSite requires cookies to specify a SameSite attribute.
+	RequireSameSite bool
+	// RequireSecure requires cookies to have the Secure attribute. It can be
+	// disabled for local development over plain HTTP.
+	RequireSecure bool
+}
+
+var (
+	// ErrInvalidCookie is returned by Header.SetCookie when the cookie is
+	// invalid, e.g. because of its name or value.
+	ErrInvalidCookie = errors.New("safehttp: invalid cookie")
+	// ErrCookiePolicy is returned by Header.SetCookie when the cookie
+	// violates the CookiePolicy.
+	ErrCookiePolicy = errors.New("safehttp: cookie violates the cookie policy")
+)
+
 func newHeader(h http.Header) Header {
 	return Header{wrapped: h, immutable: map[string]bool{}}
 }
 
-// MarkImmutable marks the header with the given name as immutable.
-// The name is first canonicalized using textproto.CanonicalMIMEHeaderKey.
-// This header is now read-only. If the header previously was
+// MarkImmutable marks the headers with the given names as immutable.
+// The names are first canonicalized using textproto.CanonicalMIMEHeaderKey.
+// These headers are now read-only. If a header previously was
 // undefined, then it will forever be undefined. Other methods in
-// the struct can't write to, change or delete the header with this
-// name. These methods will instead fail when applied on an immutable
+// the struct can't write to, change or delete the headers with these
+// names. These methods will instead fail when applied on an immutable
 // header.
-func (h Header) MarkImmutable(name string) {
-	name = textproto.CanonicalMIMEHeaderKey(name)
-	h.immutable[name] = true
+func (h Header) MarkImmutable(names ...string) {
+	for _, name := range names {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		h.immutable[name] = true
+	}
+}
+
+// IsImmutable reports whether the header with the given name is immutable,
+// i.e. whether Set, Add and Del will fail when applied on it. The name is
+// first canonicalized using textproto.CanonicalMIMEHeaderKey. The Set-Cookie
+// header is always immutable, as it can only be modified through SetCookie.
+func (h Header) IsImmutable(name string) bool {
+	return h.writableHeader(textproto.CanonicalMIMEHeaderKey(name)) != nil
 }
 
 // Set sets the header with the given name to the given value.
@@ -84,6 +117,43 @@ func (h Header) Del(name string) error {
 	return nil
 }
 
+// AddVary adds the given request header names to the Vary header, e.g.
+// after negotiating the response based on them. Names already listed are not
+// added again, and nothing is added if the Vary header is "*". The names are
+// canonicalized using textproto.CanonicalMIMEHeaderKey. Returns an error
+// when the Vary header is immutable.
+func (h Header) AddVary(names ...string) error {
+	if err := h.writableHeader("Vary"); err != nil {
+		return err
+	}
+	addVary(h.wrapped, names...)
+	return nil
+}
+
+func addVary(h http.Header, names ...string) {
+	listed := map[string]bool{}
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return
+			}
+			listed[textproto.CanonicalMIMEHeaderKey(name)] = true
+		}
+	}
+	var added []string
+	for _, name := range names {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if !listed[name] {
+			listed[name] = true
+			added = append(added, name)
+		}
+	}
+	if len(added) > 0 {
+		h.Add("Vary", strings.Join(added, ", "))
+	}
+}
+
 // Get returns the value of the first header with the given name.
 // The name is first canonicalized using textproto.CanonicalMIMEHeaderKey.
 // If no header exists with the given name then "" is returned.
@@ -105,15 +175,156 @@ func (h Header) Values(name string) []string {
 	return clone
 }
 
+// Names returns the canonicalized names of all the headers in the
+// collection, in sorted order.
+func (h Header) Names() []string {
+	names := make([]string, 0, len(h.wrapped))
+	for name := range h.wrapped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // SetCookie adds the cookie provided as a Set-Cookie header in the header
-// collection. If the cookie is nil or cookie.Name is invalid, no header is
-// added. This is the only method that can modify the Set-Cookie header.
+// collection. This is the only method that can modify the Set-Cookie header.
 // If other methods try to modify the header they will return errors.
+//
+// If the cookie is nil or invalid according to http.Cookie.Valid, e.g.
+// because of its name or value, no header is added and an error wrapping
+// ErrInvalidCookie is returned. If the cookie violates the
+// CookiePolicy of the header, no header is added and an error wrapping
+// ErrCookiePolicy is returned.
 // TODO: Replace http.Cookie with safehttp.Cookie.
-func (h Header) SetCookie(cookie *http.Cookie) {
-	if v := cookie.String(); v != "" {
-		h.wrapped.Add("Set-Cookie", v)
+func (h Header) SetCookie(cookie *http.Cookie) error {
+	if err := cookie.Valid(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCookie, err)
+	}
+	if h.cookiePolicy.RequireSameSite && (cookie.SameSite == 0 || cookie.SameSite == http.SameSiteDefaultMode) {
+		return fmt.Errorf("%w: cookie %q doesn't specify SameSite", ErrCookiePolicy, cookie.Name)
+	}
+	if h.cookiePolicy.RequireSecure && !cookie.Secure {
+		return fmt.Errorf("%w: cookie %q is not Secure", ErrCookiePolicy, cookie.Name)
+	}
+	h.wrapped.Add("Set-Cookie", cookie.String())
+	return nil
+}
+
+// DeleteCookie adds a Set-Cookie header instructing the client to delete the
+// cookie with the given name and the path "/". The cookie satisfies the
+// CookiePolicy of the header, so only an invalid name results in an error,
+// wrapping ErrInvalidCookie.
+func (h Header) DeleteCookie(name string) error {
+	c := &http.Cookie{Name: name, Path: "/", MaxAge: -1}
+	if h.cookiePolicy.RequireSameSite {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if h.cookiePolicy.RequireSecure {
+		c.Secure = true
+	}
+	return h.SetCookie(c)
+}
+
+// Cookies parses the values of the Set-Cookie header, added with SetCookie,
+// and returns the cookies they set, including their attributes. Malformed
+// values are skipped.
+func (h Header) Cookies() []*http.Cookie {
+	var cookies []*http.Cookie
+	for _, v := range h.wrapped.Values("Set-Cookie") {
+		if c := parseSetCookie(v); c != nil {
+			cookies = append(cookies, c)
+		}
+	}
+	return cookies
+}
+
+// HasCookie reports whether a cookie with the given name is set in the
+// Set-Cookie header.
+func (h Header) HasCookie(name string) bool {
+	for _, c := range h.Cookies() {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplySameSiteDefault adds the given SameSite attribute to every cookie in
+// the Set-Cookie header that doesn't specify one, and returns the names of
+// the modified cookies. It panics if mode is http.SameSiteDefaultMode.
+func (h Header) ApplySameSiteDefault(mode http.SameSite) []string {
+	var attr string
+	switch mode {
+	case http.SameSiteLaxMode:
+		attr = "; SameSite=Lax"
+	case http.SameSiteStrictMode:
+		attr = "; SameSite=Strict"
+	case http.SameSiteNoneMode:
+		attr = "; SameSite=None"
+	default:
+		panic("invalid SameSite mode")
+	}
+	var modified []string
+	values := h.wrapped["Set-Cookie"]
+	for i, v := range values {
+		c := parseSetCookie(v)
+		if c == nil || (c.SameSite != 0 && c.SameSite != http.SameSiteDefaultMode) {
+			continue
+		}
+		values[i] = v + attr
+		modified = append(modified, c.Name)
+	}
+	return modified
+}
+
+// ApplySecure adds the Secure attribute to every cookie in the Set-Cookie
+// header that doesn't have it, and returns the names of the modified
+// cookies.
+func (h Header) ApplySecure() []string {
+	var modified []string
+	values := h.wrapped["Set-Cookie"]
+	for i, v := range values {
+		c := parseSetCookie(v)
+		if c == nil || c.Secure {
+			continue
+		}
+		values[i] = v + "; Secure"
+		modified = append(modified, c.Name)
+	}
+	return modified
+}
+
+// RemoveCookies removes the cookies for which remove returns true from the
+// Set-Cookie header, and returns their names. It is meant to drop cookies
+// violating a policy, e.g. before rejecting the response. Malformed values
+// are left untouched.
+func (h Header) RemoveCookies(remove func(c *http.Cookie) bool) []string {
+	var removed []string
+	var kept []string
+	for _, v := range h.wrapped["Set-Cookie"] {
+		if c := parseSetCookie(v); c != nil && remove(c) {
+			removed = append(removed, c.Name)
+			continue
+		}
+		kept = append(kept, v)
+	}
+	if len(kept) == 0 {
+		delete(h.wrapped, "Set-Cookie")
+	} else {
+		h.wrapped["Set-Cookie"] = kept
+	}
+	return removed
+}
+
+// parseSetCookie parses the value of a Set-Cookie header. It returns nil if
+// the value is malformed.
+func parseSetCookie(v string) *http.Cookie {
+	resp := http.Response{Header: http.Header{"Set-Cookie": {v}}}
+	cookies := resp.Cookies()
+	if len(cookies) != 1 {
+		return nil
 	}
+	return cookies[0]
 }
 
 // TODO: Add Write, WriteSubset and Clone when needed.
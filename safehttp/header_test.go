@@ -15,6 +15,7 @@
 package safehttp
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 
@@ -256,12 +257,28 @@ func TestSetCookie(t *testing.T) {
 	}
 }
 
-func TestSetCookieInvalidName(t *testing.T) {
-	h := newHeader(http.Header{})
-	c := &http.Cookie{Name: "x=", Value: "y"}
-	h.SetCookie(c)
-	if got, want := h.Get("Set-Cookie"), ""; got != want {
-		t.Errorf(`h.Get("Set-Cookie") got: %q want: %q`, got, want)
+func TestSetCookieInvalid(t *testing.T) {
+	var tests = []struct {
+		name   string
+		cookie *http.Cookie
+	}{
+		{name: "Nil"},
+		{name: "Name", cookie: &http.Cookie{Name: "x=", Value: "y"}},
+		{name: "ValueSemicolon", cookie: &http.Cookie{Name: "x", Value: "y;z"}},
+		{name: "ValueQuote", cookie: &http.Cookie{Name: "x", Value: `y"z`}},
+		{name: "Domain", cookie: &http.Cookie{Name: "x", Value: "y", Domain: "example.com;x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHeader(http.Header{})
+			if err := h.SetCookie(tt.cookie); !errors.Is(err, ErrInvalidCookie) {
+				t.Errorf("h.SetCookie(c) got err: %v want: ErrInvalidCookie", err)
+			}
+			if got, want := h.Get("Set-Cookie"), ""; got != want {
+				t.Errorf(`h.Get("Set-Cookie") got: %q want: %q`, got, want)
+			}
+		})
 	}
 }
 
@@ -354,3 +371,275 @@ func TestValuesEmptyHeader(t *testing.T) {
 		t.Errorf("h.Values(\"Foo-Key\") mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestApplySameSiteDefault(t *testing.T) {
+	h := newHeader(http.Header{})
+	h.SetCookie(&http.Cookie{Name: "a", Value: "b"})
+	h.SetCookie(&http.Cookie{Name: "c", Value: "d", SameSite: http.SameSiteStrictMode})
+
+	if diff := cmp.Diff([]string{"a"}, h.ApplySameSiteDefault(http.SameSiteLaxMode)); diff != "" {
+		t.Errorf("h.ApplySameSiteDefault() mismatch (-want +got):\n%s", diff)
+	}
+	want := []string{"a=b; SameSite=Lax", "c=d; SameSite=Strict"}
+	if diff := cmp.Diff(want, h.Values("Set-Cookie")); diff != "" {
+		t.Errorf("h.Values(\"Set-Cookie\") mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplySecure(t *testing.T) {
+	h := newHeader(http.Header{})
+	h.SetCookie(&http.Cookie{Name: "a", Value: "b"})
+	h.SetCookie(&http.Cookie{Name: "c", Value: "d", Secure: true})
+
+	if diff := cmp.Diff([]string{"a"}, h.ApplySecure()); diff != "" {
+		t.Errorf("h.ApplySecure() mismatch (-want +got):\n%s", diff)
+	}
+	want := []string{"a=b; Secure", "c=d; Secure"}
+	if diff := cmp.Diff(want, h.Values("Set-Cookie")); diff != "" {
+		t.Errorf("h.Values(\"Set-Cookie\") mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRemoveCookies(t *testing.T) {
+	h := newHeader(http.Header{})
+	h.SetCookie(&http.Cookie{Name: "a", Value: "b"})
+	h.SetCookie(&http.Cookie{Name: "c", Value: "d", Secure: true})
+	h.SetCookie(&http.Cookie{Name: "e", Value: "f"})
+
+	removed := h.RemoveCookies(func(c *http.Cookie) bool { return !c.Secure })
+	if diff := cmp.Diff([]string{"a", "e"}, removed); diff != "" {
+		t.Errorf("h.RemoveCookies() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"c=d; Secure"}, h.Values("Set-Cookie")); diff != "" {
+		t.Errorf("h.Values(\"Set-Cookie\") mismatch (-want +got):\n%s", diff)
+	}
+
+	h.RemoveCookies(func(c *http.Cookie) bool { return true })
+	if diff := cmp.Diff([]string{}, h.Values("Set-Cookie")); diff != "" {
+		t.Errorf("h.Values(\"Set-Cookie\") mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNames(t *testing.T) {
+	h := newHeader(http.Header{})
+	h.Set("x-b", "1")
+	h.Add("X-A", "2")
+	h.Add("X-A", "3")
+	want := []string{"X-A", "X-B"}
+	if diff := cmp.Diff(want, h.Names()); diff != "" {
+		t.Errorf("h.Names() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMarkImmutableMultiple(t *testing.T) {
+	h := newHeader(http.Header{})
+	h.MarkImmutable("Foo-Key", "bar-key")
+	if err := h.Set("Foo-Key", "Pizza-Value"); err == nil {
+		t.Error(`h.Set("Foo-Key", "Pizza-Value") got: nil want: error`)
+	}
+	if err := h.Set("Bar-Key", "Pizza-Value"); err == nil {
+		t.Error(`h.Set("Bar-Key", "Pizza-Value") got: nil want: error`)
+	}
+}
+
+func TestIsImmutable(t *testing.T) {
+	h := newHeader(http.Header{})
+	h.MarkImmutable("Foo-Key")
+	var tests = []struct {
+		name string
+		want bool
+	}{
+		{name: "Foo-Key", want: true},
+		{name: "foo-key", want: true},
+		{name: "Bar-Key", want: false},
+		{name: "set-cookie", want: true},
+	}
+	for _, tt := range tests {
+		if got := h.IsImmutable(tt.name); got != tt.want {
+			t.Errorf("h.IsImmutable(%q) got: %v want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHeaderCookies(t *testing.T) {
+	h := newHeader(http.Header{})
+	h.SetCookie(&http.Cookie{Name: "session", Value: "abc", Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode, MaxAge: 3600})
+	h.SetCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	want := []*http.Cookie{
+		{Name: "session", Value: "abc", Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode, MaxAge: 3600},
+		{Name: "theme", Value: "dark"},
+	}
+	got := h.Cookies()
+	if len(got) != len(want) {
+		t.Fatalf("len(h.Cookies()) got: %v want: %v", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].String() != want[i].String() {
+			t.Errorf("h.Cookies()[%d] got: %q want: %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestHeaderHasCookie(t *testing.T) {
+	h := newHeader(http.Header{})
+	h.SetCookie(&http.Cookie{Name: "session", Value: "abc"})
+	if !h.HasCookie("session") {
+		t.Error(`h.HasCookie("session") got: false want: true`)
+	}
+	if h.HasCookie("theme") {
+		t.Error(`h.HasCookie("theme") got: true want: false`)
+	}
+}
+
+func TestSetCookiePolicy(t *testing.T) {
+	var tests = []struct {
+		name    string
+		policy  CookiePolicy
+		cookie  *http.Cookie
+		wantErr error
+	}{
+		{
+			name:   "NoPolicy",
+			cookie: &http.Cookie{Name: "x", Value: "y"},
+		},
+		{
+			name:   "Compliant",
+			policy: CookiePolicy{RequireSameSite: true, RequireSecure: true},
+			cookie: &http.Cookie{Name: "x", Value: "y", Secure: true, SameSite: http.SameSiteLaxMode},
+		},
+		{
+			name:    "MissingSameSite",
+			policy:  CookiePolicy{RequireSameSite: true},
+			cookie:  &http.Cookie{Name: "x", Value: "y"},
+			wantErr: ErrCookiePolicy,
+		},
+		{
+			name:    "DefaultSameSite",
+			policy:  CookiePolicy{RequireSameSite: true},
+			cookie:  &http.Cookie{Name: "x", Value: "y", SameSite: http.SameSiteDefaultMode},
+			wantErr: ErrCookiePolicy,
+		},
+		{
+			name:    "NotSecure",
+			policy:  CookiePolicy{RequireSecure: true},
+			cookie:  &http.Cookie{Name: "x", Value: "y"},
+			wantErr: ErrCookiePolicy,
+		},
+		{
+			name:   "InsecureAllowed",
+			policy: CookiePolicy{RequireSameSite: true},
+			cookie: &http.Cookie{Name: "x", Value: "y", SameSite: http.SameSiteStrictMode},
+		},
+		{
+			name:    "InvalidName",
+			policy:  CookiePolicy{RequireSameSite: true, RequireSecure: true},
+			cookie:  &http.Cookie{Name: "x=", Value: "y"},
+			wantErr: ErrInvalidCookie,
+		},
+		{
+			name:    "InvalidValue",
+			policy:  CookiePolicy{RequireSameSite: true, RequireSecure: true},
+			cookie:  &http.Cookie{Name: "x", Value: "y;z", Secure: true, SameSite: http.SameSiteLaxMode},
+			wantErr: ErrInvalidCookie,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHeader(http.Header{})
+			h.cookiePolicy = tt.policy
+			err := h.SetCookie(tt.cookie)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("h.SetCookie() got err: %v want: %v", err, tt.wantErr)
+			}
+			if got, want := len(h.Values("Set-Cookie")) == 1, tt.wantErr == nil; got != want {
+				t.Errorf("cookie set got: %v want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDeleteCookie(t *testing.T) {
+	var tests = []struct {
+		name   string
+		policy CookiePolicy
+		want   string
+	}{
+		{
+			name: "NoPolicy",
+			want: "session=; Path=/; Max-Age=0",
+		},
+		{
+			name:   "Policy",
+			policy: CookiePolicy{RequireSameSite: true, RequireSecure: true},
+			want:   "session=; Path=/; Max-Age=0; Secure; SameSite=Lax",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHeader(http.Header{})
+			h.cookiePolicy = tt.policy
+			if err := h.DeleteCookie("session"); err != nil {
+				t.Fatalf(`h.DeleteCookie("session") got err: %v want: nil`, err)
+			}
+			if got := h.Values("Set-Cookie"); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("h.Values(\"Set-Cookie\") got: %q want: [%q]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	var tests = []struct {
+		name     string
+		existing []string
+		add      []string
+		want     []string
+	}{
+		{
+			name: "Empty",
+			add:  []string{"accept", "Accept-Encoding"},
+			want: []string{"Accept, Accept-Encoding"},
+		},
+		{
+			name:     "AlreadyListed",
+			existing: []string{"Accept-Encoding, accept"},
+			add:      []string{"Accept", "accept-language"},
+			want:     []string{"Accept-Encoding, accept", "Accept-Language"},
+		},
+		{
+			name:     "NothingNew",
+			existing: []string{"Accept"},
+			add:      []string{"Accept", "accept"},
+			want:     []string{"Accept"},
+		},
+		{
+			name:     "Wildcard",
+			existing: []string{"*"},
+			add:      []string{"Accept"},
+			want:     []string{"*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHeader(http.Header{"Vary": tt.existing})
+			if err := h.AddVary(tt.add...); err != nil {
+				t.Fatalf("h.AddVary(%v) got err: %v want: nil", tt.add, err)
+			}
+			if diff := cmp.Diff(tt.want, h.Values("Vary")); diff != "" {
+				t.Errorf(`h.Values("Vary") mismatch (-want +got):\n%s`, diff)
+			}
+		})
+	}
+}
+
+func TestAddVaryImmutable(t *testing.T) {
+	h := newHeader(http.Header{})
+	h.MarkImmutable("Vary")
+	if err := h.AddVary("Accept"); err == nil {
+		t.Error(`h.AddVary("Accept") got: nil want: error`)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HostMux dispatches requests to the handler registered for their host,
+// typically a ServeMux, so that a single server can serve several hosts with
+// different handlers and interceptors.
+//
+// Hosts are compared case-insensitively, ignoring the port of the request.
+// Requests for other hosts are passed to the fallback handler, which responds
+// with 404 Not Found by default. HostMux doesn't validate hosts: malformed
+// hosts never match a registered host and reach the fallback, so validation
+// interceptors (e.g. plugins/hostvalidation) should be installed on the
+// fallback as well as on the ServeMuxes.
+type HostMux struct {
+	hosts    map[string]http.Handler
+	fallback http.Handler
+}
+
+// NewHostMux creates an empty HostMux.
+func NewHostMux() *HostMux {
+	return &HostMux{
+		hosts:    map[string]http.Handler{},
+		fallback: http.NotFoundHandler(),
+	}
+}
+
+// Handle registers the handler for the given host, without port, e.g.
+// "api.example.com". It panics if a handler is already registered for the
+// host.
+func (hm *HostMux) Handle(host string, h http.Handler) {
+	host = strings.ToLower(host)
+	if _, ok := hm.hosts[host]; ok {
+		panic(fmt.Sprintf("safehttp: multiple registrations for host %s", host))
+	}
+	hm.hosts[host] = h
+}
+
+// SetFallback sets the handler serving the requests for hosts without a
+// registered handler.
+func (hm *HostMux) SetFallback(h http.Handler) {
+	hm.fallback = h
+}
+
+// ServeHTTP dispatches the request to the handler registered for its host.
+func (hm *HostMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h, ok := hm.hosts[stripPort(strings.ToLower(r.Host))]; ok {
+		h.ServeHTTP(w, r)
+		return
+	}
+	hm.fallback.ServeHTTP(w, r)
+}
+
+// stripPort removes the port from host, if any. Brackets around IPv6
+// addresses are kept only if there's no port, like in the Host header.
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		if strings.Contains(h, ":") {
+			return "[" + h + "]"
+		}
+		return h
+	}
+	return host
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostMux(t *testing.T) {
+	newMux := func(name string) *ServeMux {
+		m := NewServeMux(nil)
+		m.Handle("/", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+			return w.writeBody("text/plain; charset=utf-8", []byte(name))
+		})
+		return m
+	}
+	hm := NewHostMux()
+	hm.Handle("api.example.com", newMux("api"))
+	hm.Handle("WWW.example.com", newMux("www"))
+	hm.Handle("[::1]", newMux("ipv6"))
+
+	var tests = []struct {
+		name     string
+		host     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "Exact", host: "api.example.com", wantCode: http.StatusOK, wantBody: "api"},
+		{name: "CaseInsensitive", host: "www.EXAMPLE.com", wantCode: http.StatusOK, wantBody: "www"},
+		{name: "Port", host: "api.example.com:8443", wantCode: http.StatusOK, wantBody: "api"},
+		{name: "IPv6", host: "[::1]", wantCode: http.StatusOK, wantBody: "ipv6"},
+		{name: "IPv6Port", host: "[::1]:8080", wantCode: http.StatusOK, wantBody: "ipv6"},
+		{name: "Unknown", host: "example.com", wantCode: http.StatusNotFound, wantBody: "404 page not found\n"},
+		{name: "Suffix", host: "api.example.com.evil.com", wantCode: http.StatusNotFound, wantBody: "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			hm.ServeHTTP(rec, req)
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHostMuxFallback(t *testing.T) {
+	fallback := NewServeMux(nil)
+	fallback.Handle("/", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.ClientError(Status421MisdirectedRequest)
+	})
+	hm := NewHostMux()
+	hm.SetFallback(fallback)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Host = "unknown.example.com"
+	rec := httptest.NewRecorder()
+	hm.ServeHTTP(rec, req)
+
+	if got, want := rec.Code, http.StatusMisdirectedRequest; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+}
+
+func TestHostMuxDuplicateRegistration(t *testing.T) {
+	hm := NewHostMux()
+	hm.Handle("example.com", NewServeMux(nil))
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error(`hm.Handle("EXAMPLE.com", m) expected panic`)
+		}
+	}()
+	hm.Handle("EXAMPLE.com", NewServeMux(nil))
+}
@@ -14,7 +14,14 @@
 
 package safehttp
 
-import "net/http"
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+)
 
 // IncomingRequest TODO
 type IncomingRequest struct {
@@ -25,3 +32,114 @@ type IncomingRequest struct {
 func newIncomingRequest(req *http.Request) IncomingRequest {
 	return IncomingRequest{req: req, Header: newHeader(req.Header)}
 }
+
+// NewIncomingRequest creates an IncomingRequest wrapping req. It is meant for
+// testing handlers in isolation (see package safehttptest).
+func NewIncomingRequest(req *http.Request) *IncomingRequest {
+	ir := newIncomingRequest(req)
+	return &ir
+}
+
+// Context returns the context of the request.
+func (r *IncomingRequest) Context() context.Context {
+	return r.req.Context()
+}
+
+// SetContext replaces the context of the request, e.g. with a context
+// derived from it with a deadline.
+func (r *IncomingRequest) SetContext(ctx context.Context) {
+	r.req = r.req.WithContext(ctx)
+}
+
+// Body returns the body of the request. It is always non-nil, but returns
+// EOF immediately when the request has no body.
+func (r *IncomingRequest) Body() io.ReadCloser {
+	return r.req.Body
+}
+
+// SetBody replaces the body of the request, e.g. with a decoded version of
+// it. The length of the new body is considered unknown. Headers describing
+// the original body, like Content-Length, are left untouched.
+func (r *IncomingRequest) SetBody(body io.ReadCloser) {
+	r.req.Body = body
+	r.req.ContentLength = -1
+}
+
+// ContentLength returns the length of the body of the request, as declared
+// by its Content-Length header. It reports false if the length is unknown,
+// e.g. because the body was replaced with SetBody.
+func (r *IncomingRequest) ContentLength() (int64, bool) {
+	return r.req.ContentLength, r.req.ContentLength >= 0
+}
+
+// PostForm parses the body of the request as an URL-encoded form and returns
+// the form values. The body is only parsed once, subsequent calls return the
+// same values. Other content types result in empty values.
+func (r *IncomingRequest) PostForm() (url.Values, error) {
+	if err := r.req.ParseForm(); err != nil {
+		return nil, err
+	}
+	clone := make(url.Values, len(r.req.PostForm))
+	for k, v := range r.req.PostForm {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone, nil
+}
+
+// Host returns the host the request is targeted to, as sent by the client in
+// the request line or the Host header. It may include a port.
+func (r *IncomingRequest) Host() string {
+	return r.req.Host
+}
+
+// TLS returns the state of the TLS connection the request was received on,
+// or nil if the request wasn't received over TLS.
+func (r *IncomingRequest) TLS() *tls.ConnectionState {
+	return r.req.TLS
+}
+
+// IsTLS reports whether the request was received over TLS. Requests forwarded
+// over plain HTTP by a proxy terminating TLS are not.
+func (r *IncomingRequest) IsTLS() bool {
+	return r.req.TLS != nil
+}
+
+// Method returns the HTTP method of the request.
+func (r *IncomingRequest) Method() string {
+	return r.req.Method
+}
+
+// URL returns a copy of the URL of the request.
+func (r *IncomingRequest) URL() *url.URL {
+	u := *r.req.URL
+	return &u
+}
+
+// RemoteAddr returns the network address of the client that sent the
+// request, usually in the "IP:port" form. It is the address of the direct
+// peer, which might be a proxy.
+func (r *IncomingRequest) RemoteAddr() string {
+	return r.req.RemoteAddr
+}
+
+// ErrNoCookie is returned by IncomingRequest.Cookie when the request doesn't
+// carry the requested cookie.
+var ErrNoCookie = errors.New("safehttp: named cookie not present")
+
+// Cookie returns the cookie with the given name sent in the request. If
+// multiple cookies match the name, the first one is returned. It returns
+// ErrNoCookie if the cookie is not present. The value of signed cookies is
+// verified with CookieSigner.Cookie instead.
+func (r *IncomingRequest) Cookie(name string) (*http.Cookie, error) {
+	c, err := r.req.Cookie(name)
+	if err != nil {
+		return nil, ErrNoCookie
+	}
+	return c, nil
+}
+
+// Cookies returns all the cookies sent in the request. Malformed cookies are
+// skipped.
+func (r *IncomingRequest) Cookies() []*http.Cookie {
+	return r.req.Cookies()
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCookie(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Cookie", "a=b; c=d; a=e")
+	ir := newIncomingRequest(req)
+
+	c, err := ir.Cookie("a")
+	if err != nil {
+		t.Fatalf(`ir.Cookie("a") got err: %v want: nil`, err)
+	}
+	if got, want := c.Value, "b"; got != want {
+		t.Errorf(`ir.Cookie("a").Value got: %q want: %q`, got, want)
+	}
+}
+
+func TestCookieMissing(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Cookie", "a=b")
+	ir := newIncomingRequest(req)
+
+	if _, err := ir.Cookie("x"); err != ErrNoCookie {
+		t.Errorf(`ir.Cookie("x") got err: %v want: %v`, err, ErrNoCookie)
+	}
+}
+
+func TestSignedCookie(t *testing.T) {
+	s, err := NewCookieSigner(testCookieKey)
+	if err != nil {
+		t.Fatalf("NewCookieSigner() got err: %v", err)
+	}
+	signed := s.Sign(&http.Cookie{Name: "flash", Value: "Saved"})
+	// The first character of the signed payload is changed.
+	tampered := "B" + signed.Value[1:]
+	if tampered == signed.Value {
+		tampered = "A" + signed.Value[1:]
+	}
+	var tests = []struct {
+		name      string
+		cookie    string
+		wantValue string
+		wantErr   error
+	}{
+		{
+			name:      "Present",
+			cookie:    "flash=" + signed.Value,
+			wantValue: "Saved",
+		},
+		{
+			name:    "Missing",
+			cookie:  "other=" + signed.Value,
+			wantErr: ErrNoCookie,
+		},
+		{
+			name:    "Tampered",
+			cookie:  "flash=" + tampered,
+			wantErr: ErrInvalidCookieSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Cookie", tt.cookie)
+			ir := newIncomingRequest(req)
+
+			got, err := s.Cookie(&ir, "flash")
+			if err != tt.wantErr {
+				t.Errorf(`s.Cookie(ir, "flash") got err: %v want: %v`, err, tt.wantErr)
+			}
+			if got != tt.wantValue {
+				t.Errorf(`s.Cookie(ir, "flash") got: %q want: %q`, got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestCookies(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Cookie", "a=b; c=d")
+	ir := newIncomingRequest(req)
+
+	var got []string
+	for _, c := range ir.Cookies() {
+		got = append(got, c.Name+"="+c.Value)
+	}
+	if diff := cmp.Diff([]string{"a=b", "c=d"}, got); diff != "" {
+		t.Errorf("ir.Cookies() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"log"
+	"runtime/debug"
+	"sort"
+	"sync/atomic"
+)
+
+// Interceptor alters the processing of incoming requests and outgoing
+// responses. Interceptors are installed on a Machinery and run for every
+// request it handles, wrapping each other: Before runs in the order the
+// interceptors were installed and Commit in the reverse order. The order can
+// be changed by giving interceptors a priority with WithPriority.
+type Interceptor interface {
+	// Before runs before the IncomingRequest is passed to the handler. If
+	// Before writes a response, the Before phase of the remaining
+	// interceptors and the handler are not run. The Commit phase still runs
+	// for every enabled interceptor, including the ones whose Before didn't
+	// run, so that the response carries their headers, e.g. security
+	// headers on a rejection.
+	Before(w ResponseWriter, r *IncomingRequest) Result
+
+	// Commit runs right before the response is written by the Dispatcher,
+	// so headers set on the ResponseWriter are still part of the response.
+	// If Commit writes an error response, the original response is dropped
+	// and the remaining interceptors commit the error response instead, so
+	// that it still carries their headers. Interceptors needing the status
+	// code of the response actually sent implement Finisher.
+	Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result
+}
+
+// Finisher is implemented by interceptors that need the status code the
+// response was actually sent with, e.g. to log it. Commit doesn't always see
+// it: an interceptor committing later can still replace the response with an
+// error, and a panicking interceptor replaces it with 500 Internal Server
+// Error.
+type Finisher interface {
+	// Finish runs once the request was handled, after the response was
+	// written, with its status code. The code is 0 if no response was
+	// written. Like Commit, Finish runs in the reverse order of Before, for
+	// every enabled interceptor.
+	Finish(r *IncomingRequest, code StatusCode)
+}
+
+// finish runs Finish on the interceptors implementing Finisher, in reverse
+// order. A panicking interceptor doesn't prevent the following ones from
+// running.
+func finish(interceptors []Interceptor, r *IncomingRequest, code StatusCode) {
+	for k := len(interceptors) - 1; k >= 0; k-- {
+		if f, ok := unwrap(interceptors[k]).(Finisher); ok {
+			finishInterceptor(f, r, code)
+		}
+	}
+}
+
+// unwrap returns the interceptor wrapped by WithToggle and WithPriority.
+func unwrap(i Interceptor) Interceptor {
+	for {
+		switch w := i.(type) {
+		case toggledInterceptor:
+			i = w.Interceptor
+		case prioritizedInterceptor:
+			i = w.Interceptor
+		default:
+			return i
+		}
+	}
+}
+
+func finishInterceptor(f Finisher, r *IncomingRequest, code StatusCode) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("safehttp: panic in Finish of %T: %v\n%s", f, v, debug.Stack())
+		}
+	}()
+	f.Finish(r, code)
+}
+
+// Toggle is a switch that enables or disables an interceptor at runtime, e.g.
+// to turn off rate limiting without redeploying. The zero value is enabled.
+// It is safe for concurrent use.
+//
+// Interceptors can embed a *Toggle to expose it to the Machinery, or be
+// wrapped using WithToggle.
+type Toggle struct {
+	disabled int32
+}
+
+// Enable enables the interceptor for subsequent requests.
+func (t *Toggle) Enable() {
+	atomic.StoreInt32(&t.disabled, 0)
+}
+
+// Disable disables the interceptor for subsequent requests.
+func (t *Toggle) Disable() {
+	atomic.StoreInt32(&t.disabled, 1)
+}
+
+// Enabled reports whether the interceptor is enabled.
+func (t *Toggle) Enabled() bool {
+	return atomic.LoadInt32(&t.disabled) == 0
+}
+
+// toggleable is implemented by interceptors that can be disabled at runtime.
+// The Machinery checks Enabled once per request: a disabled interceptor runs
+// neither Before nor Commit for that request.
+type toggleable interface {
+	Enabled() bool
+}
+
+type toggledInterceptor struct {
+	Interceptor
+	*Toggle
+}
+
+// WithToggle returns an interceptor that behaves like i while t is enabled
+// and is skipped while t is disabled.
+func WithToggle(i Interceptor, t *Toggle) Interceptor {
+	return toggledInterceptor{Interceptor: i, Toggle: t}
+}
+
+// enabledInterceptors returns the interceptors that are enabled at the time
+// of the call.
+func enabledInterceptors(is []Interceptor) []Interceptor {
+	res := make([]Interceptor, 0, len(is))
+	for _, i := range is {
+		if p, ok := i.(prioritizedInterceptor); ok {
+			i = p.Interceptor
+		}
+		if t, ok := i.(toggleable); ok && !t.Enabled() {
+			continue
+		}
+		res = append(res, i)
+	}
+	return res
+}
+
+// prioritized is implemented by interceptors with a priority. Interceptors
+// can implement it themselves or be wrapped using WithPriority.
+type prioritized interface {
+	Priority() int
+}
+
+type prioritizedInterceptor struct {
+	Interceptor
+	priority int
+}
+
+func (p prioritizedInterceptor) Priority() int {
+	return p.priority
+}
+
+// WithPriority returns an interceptor that behaves like i, with the given
+// priority. The Before phase of interceptors runs in ascending order of
+// priority and the Commit phase in descending order, so interceptors with a
+// lower priority wrap the ones with a higher priority. Interceptors without a
+// priority have priority 0. Interceptors with equal priorities run in the
+// order they were installed.
+func WithPriority(i Interceptor, priority int) Interceptor {
+	return prioritizedInterceptor{Interceptor: i, priority: priority}
+}
+
+func priorityOf(i Interceptor) int {
+	if p, ok := i.(prioritized); ok {
+		return p.Priority()
+	}
+	if t, ok := i.(toggledInterceptor); ok {
+		return priorityOf(t.Interceptor)
+	}
+	return 0
+}
+
+// installInterceptor adds i to the interceptors, keeping them sorted by
+// priority and, for equal priorities, in installation order.
+func installInterceptor(is []Interceptor, i Interceptor) []Interceptor {
+	is = append(is, i)
+	sort.SliceStable(is, func(a, b int) bool {
+		return priorityOf(is[a]) < priorityOf(is[b])
+	})
+	return is
+}
+
+// InterceptorConfig configures an installed interceptor for the requests
+// served by a single handler, e.g. to disable XSRF protection on an endpoint
+// authenticating its callers differently. Each interceptor defines and
+// interprets its own config types. Configs are passed to ServeMux.Handle.
+type InterceptorConfig interface{}
+
+// ConfigurableInterceptor is an Interceptor that can be configured for the
+// requests served by a single handler.
+type ConfigurableInterceptor interface {
+	Interceptor
+
+	// Configure reports whether cfg is targeted at the interceptor and, if
+	// so, returns the interceptor to run instead for the requests served by
+	// the handler, or nil to skip the interceptor entirely for them.
+	Configure(cfg InterceptorConfig) (Interceptor, bool)
+}
+
+// configure applies cfg to i, looking through the wrappers added by
+// WithToggle and WithPriority. It reports whether cfg is targeted at i.
+func configure(i Interceptor, cfg InterceptorConfig) (Interceptor, bool) {
+	switch w := i.(type) {
+	case prioritizedInterceptor:
+		c, ok := configure(w.Interceptor, cfg)
+		if ok && c != nil {
+			c = prioritizedInterceptor{Interceptor: c, priority: w.priority}
+		}
+		return c, ok
+	case toggledInterceptor:
+		c, ok := configure(w.Interceptor, cfg)
+		if ok && c != nil {
+			c = toggledInterceptor{Interceptor: c, Toggle: w.Toggle}
+		}
+		return c, ok
+	case ConfigurableInterceptor:
+		return w.Configure(cfg)
+	}
+	return i, false
+}
+
+// configureInterceptors returns the interceptors to run for a handler
+// registered with the given configs. Configs are applied in order to every
+// interceptor they are targeted at. Interceptors disabled by a config are
+// left out.
+func configureInterceptors(is []Interceptor, cfgs []InterceptorConfig) []Interceptor {
+	if len(cfgs) == 0 {
+		return is
+	}
+	res := make([]Interceptor, 0, len(is))
+	for _, i := range is {
+		for _, cfg := range cfgs {
+			if c, ok := configure(i, cfg); ok {
+				i = c
+			}
+			if i == nil {
+				break
+			}
+		}
+		if i != nil {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// targetsAny reports whether cfg is targeted at any of the interceptors.
+func targetsAny(is []Interceptor, cfg InterceptorConfig) bool {
+	for _, i := range is {
+		if _, ok := configure(i, cfg); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,357 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type countingInterceptor struct {
+	before, commit *int
+}
+
+func (it countingInterceptor) Before(w ResponseWriter, r *IncomingRequest) Result {
+	*it.before++
+	return Result{}
+}
+
+func (it countingInterceptor) Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result {
+	*it.commit++
+	return Result{}
+}
+
+type toggledCountingInterceptor struct {
+	countingInterceptor
+	*Toggle
+}
+
+func TestToggle(t *testing.T) {
+	var tests = []struct {
+		name  string
+		setup func(countingInterceptor, *Toggle) Interceptor
+	}{
+		{
+			name: "WithToggle",
+			setup: func(it countingInterceptor, tg *Toggle) Interceptor {
+				return WithToggle(it, tg)
+			},
+		},
+		{
+			name: "Embedded",
+			setup: func(it countingInterceptor, tg *Toggle) Interceptor {
+				return toggledCountingInterceptor{countingInterceptor: it, Toggle: tg}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before, commit int
+			tg := &Toggle{}
+			m := NewMachinery(func(rw ResponseWriter, _ *IncomingRequest) Result {
+				return rw.NoContent()
+			}, nil)
+			m.Install(tt.setup(countingInterceptor{before: &before, commit: &commit}, tg))
+
+			m.HandleRequest(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			if before != 1 || commit != 1 {
+				t.Errorf("enabled: got before: %d commit: %d want: 1, 1", before, commit)
+			}
+
+			tg.Disable()
+			m.HandleRequest(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			if before != 1 || commit != 1 {
+				t.Errorf("disabled: got before: %d commit: %d want: 1, 1", before, commit)
+			}
+
+			tg.Enable()
+			m.HandleRequest(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			if before != 2 || commit != 2 {
+				t.Errorf("re-enabled: got before: %d commit: %d want: 2, 2", before, commit)
+			}
+		})
+	}
+}
+
+type panickingInterceptor struct{}
+
+func (panickingInterceptor) Before(w ResponseWriter, r *IncomingRequest) Result {
+	return Result{}
+}
+
+func (panickingInterceptor) Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result {
+	panic("commit failed")
+}
+
+func TestCommitPanicIsolation(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var before, commit int
+	m := NewMachinery(func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	}, nil)
+	// Commit runs in the reverse order, so the counting interceptor commits
+	// after the panicking one.
+	m.Install(countingInterceptor{before: &before, commit: &commit})
+	m.Install(panickingInterceptor{})
+
+	rec := httptest.NewRecorder()
+	m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+	if commit != 1 {
+		t.Errorf("commit got: %d want: 1", commit)
+	}
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := logs.String(), "commit failed"; !strings.Contains(got, want) {
+		t.Errorf("log got: %q want it to contain %q", got, want)
+	}
+}
+
+type recordingInterceptor struct {
+	name  string
+	calls *[]string
+}
+
+func (it recordingInterceptor) Before(w ResponseWriter, r *IncomingRequest) Result {
+	*it.calls = append(*it.calls, "Before "+it.name)
+	return Result{}
+}
+
+func (it recordingInterceptor) Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result {
+	*it.calls = append(*it.calls, "Commit "+it.name)
+	return Result{}
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	var calls []string
+	m := NewMachinery(func(w ResponseWriter, r *IncomingRequest) Result {
+		calls = append(calls, "handler")
+		return w.NoContent()
+	}, nil)
+	m.Install(recordingInterceptor{name: "a", calls: &calls})
+	m.Install(WithPriority(recordingInterceptor{name: "audit", calls: &calls}, -1))
+	m.Install(recordingInterceptor{name: "b", calls: &calls})
+	m.Install(WithToggle(WithPriority(recordingInterceptor{name: "last", calls: &calls}, 1), &Toggle{}))
+	m.Install(WithPriority(WithToggle(recordingInterceptor{name: "c", calls: &calls}, &Toggle{}), 0))
+
+	m.HandleRequest(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	want := []string{
+		"Before audit", "Before a", "Before b", "Before c", "Before last",
+		"handler",
+		"Commit last", "Commit c", "Commit b", "Commit a", "Commit audit",
+	}
+	if diff := cmp.Diff(want, calls); diff != "" {
+		t.Errorf("calls mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPriorityKeepsToggle(t *testing.T) {
+	var calls []string
+	tg := &Toggle{}
+	tg.Disable()
+	m := NewMachinery(func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	}, nil)
+	m.Install(WithPriority(WithToggle(recordingInterceptor{name: "a", calls: &calls}, tg), 1))
+
+	m.HandleRequest(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if len(calls) != 0 {
+		t.Errorf("calls got: %v want: none", calls)
+	}
+}
+
+type finishingInterceptor struct {
+	recordingInterceptor
+	codes *[]StatusCode
+}
+
+func (it finishingInterceptor) Finish(r *IncomingRequest, code StatusCode) {
+	*it.calls = append(*it.calls, "Finish "+it.name)
+	*it.codes = append(*it.codes, code)
+}
+
+type rejectingInterceptor struct {
+	before, commit bool
+}
+
+func (it rejectingInterceptor) Before(w ResponseWriter, r *IncomingRequest) Result {
+	if it.before {
+		return w.ClientError(Status403Forbidden)
+	}
+	return Result{}
+}
+
+func (it rejectingInterceptor) Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result {
+	if it.commit {
+		return w.ServerError(Status503ServiceUnavailable, nil)
+	}
+	return Result{}
+}
+
+func TestFinish(t *testing.T) {
+	var tests = []struct {
+		name      string
+		rejecting rejectingInterceptor
+		wantCalls []string
+		wantCode  StatusCode
+	}{
+		{
+			name:      "NoContent",
+			wantCalls: []string{"Before a", "Before b", "Commit b", "Commit a", "Finish b", "Finish a"},
+			wantCode:  Status204NoContent,
+		},
+		{
+			name:      "RejectedInCommit",
+			rejecting: rejectingInterceptor{commit: true},
+			wantCalls: []string{"Before a", "Before b", "Commit b", "Commit a", "Finish b", "Finish a"},
+			wantCode:  Status503ServiceUnavailable,
+		},
+		{
+			name:      "RejectedInBefore",
+			rejecting: rejectingInterceptor{before: true},
+			wantCalls: []string{"Before a", "Commit b", "Commit a", "Finish b", "Finish a"},
+			wantCode:  Status403Forbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			var codes []StatusCode
+			m := NewMachinery(func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.NoContent()
+			}, nil)
+			m.Install(WithToggle(finishingInterceptor{recordingInterceptor: recordingInterceptor{name: "a", calls: &calls}, codes: &codes}, &Toggle{}))
+			m.Install(tt.rejecting)
+			m.Install(WithPriority(finishingInterceptor{recordingInterceptor: recordingInterceptor{name: "b", calls: &calls}, codes: &codes}, 1))
+
+			m.HandleRequest(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+			if diff := cmp.Diff(tt.wantCalls, calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+			for _, code := range codes {
+				if code != tt.wantCode {
+					t.Errorf("Finish code got: %v want: %v", code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+type statusSeeingInterceptor struct {
+	code *StatusCode
+	resp *Response
+}
+
+func (it statusSeeingInterceptor) Before(w ResponseWriter, r *IncomingRequest) Result {
+	return Result{}
+}
+
+func (it statusSeeingInterceptor) Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result {
+	*it.code = w.StatusCode()
+	*it.resp = resp
+	w.Header().Set("X-Committed", "true")
+	return Result{}
+}
+
+type replacingInterceptor struct {
+	code StatusCode
+	resp Response
+}
+
+func (it replacingInterceptor) Before(w ResponseWriter, r *IncomingRequest) Result {
+	return Result{}
+}
+
+func (it replacingInterceptor) Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result {
+	return w.ServerError(it.code, it.resp)
+}
+
+func TestCommitErrorReplacesResponse(t *testing.T) {
+	var code StatusCode
+	var resp Response
+	m := NewMachinery(func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	}, nil)
+	// Commit runs in the reverse order: the last replacement wins, and the
+	// first interceptor commits it.
+	m.Install(statusSeeingInterceptor{code: &code, resp: &resp})
+	m.Install(replacingInterceptor{code: Status503ServiceUnavailable, resp: "unavailable"})
+	m.Install(replacingInterceptor{code: Status500InternalServerError, resp: "failed"})
+
+	rec := httptest.NewRecorder()
+	m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Body.String(), "Service Unavailable\n"; got != want {
+		t.Errorf("rec.Body got: %q want: %q", got, want)
+	}
+	if got, want := rec.Header().Get("X-Committed"), "true"; got != want {
+		t.Errorf(`rec.Header().Get("X-Committed") got: %q want: %q`, got, want)
+	}
+	if code != Status503ServiceUnavailable || resp != "unavailable" {
+		t.Errorf(`Commit got: (%v, %v) want: (%v, "unavailable")`, code, resp, Status503ServiceUnavailable)
+	}
+}
+
+func TestBeforeRejectionCommitsAll(t *testing.T) {
+	var calls []string
+	var code StatusCode
+	var resp Response
+	m := NewMachinery(func(w ResponseWriter, r *IncomingRequest) Result {
+		calls = append(calls, "handler")
+		return w.NoContent()
+	}, nil)
+	m.Install(recordingInterceptor{name: "a", calls: &calls})
+	m.Install(rejectingInterceptor{before: true})
+	m.Install(recordingInterceptor{name: "b", calls: &calls})
+	m.Install(statusSeeingInterceptor{code: &code, resp: &resp})
+
+	rec := httptest.NewRecorder()
+	m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+	want := []string{"Before a", "Commit b", "Commit a"}
+	if diff := cmp.Diff(want, calls); diff != "" {
+		t.Errorf("calls mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := rec.Code, http.StatusForbidden; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	// The interceptor installed after the rejecting one never ran Before,
+	// but its headers are still part of the rejection.
+	if got, want := rec.Header().Get("X-Committed"), "true"; got != want {
+		t.Errorf(`rec.Header().Get("X-Committed") got: %q want: %q`, got, want)
+	}
+	if code != Status403Forbidden {
+		t.Errorf("Commit code got: %v want: %v", code, Status403Forbidden)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// JSONPrefix is prepended to the responses written by WriteJSON, unless
+// disabled, to prevent them from being loaded and evaluated as scripts by
+// other origins (JSON hijacking). Clients must strip it before parsing the
+// response.
+const JSONPrefix = ")]}'\n"
+
+// JSONResponse is a response written as JSON by ResponseWriter.WriteJSON.
+type JSONResponse struct {
+	// Data is the value encoded as the body of the response, using
+	// encoding/json.
+	Data interface{}
+	// NoPrefix disables the JSONPrefix, e.g. for public APIs whose clients
+	// don't expect it.
+	NoPrefix bool
+}
+
+// WriteJSON writes resp.Data encoded as JSON with 200 OK, after running the
+// commit phase of the installed interceptors. The body is prefixed with
+// JSONPrefix unless resp.NoPrefix is set. The Content-Type is
+// "application/json; charset=utf-8" and X-Content-Type-Options is set to
+// "nosniff".
+//
+// The data is encoded before anything is written: if encoding fails, a 500
+// Internal Server Error is written instead.
+func (w *ResponseWriter) WriteJSON(resp JSONResponse) Result {
+	b, err := json.Marshal(resp.Data)
+	if err != nil {
+		log.Printf("safehttp: encoding JSON response: %v", err)
+		return w.ServerError(Status500InternalServerError, nil)
+	}
+	if !w.commit(Status200OK, resp) {
+		return Result{}
+	}
+	if !resp.NoPrefix {
+		b = append([]byte(JSONPrefix), b...)
+	}
+	h := w.rw.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.rw.WriteHeader(int(Status200OK))
+	w.rw.Write(b)
+	return Result{}
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	var tests = []struct {
+		name     string
+		resp     JSONResponse
+		wantBody string
+	}{
+		{
+			name:     "Prefix",
+			resp:     JSONResponse{Data: map[string]int{"b": 2, "a": 1}},
+			wantBody: ")]}'\n{\"a\":1,\"b\":2}",
+		},
+		{
+			name:     "NoPrefix",
+			resp:     JSONResponse{Data: []string{"x"}, NoPrefix: true},
+			wantBody: `["x"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ir := newIncomingRequest(httptest.NewRequest("GET", "/", nil))
+			rec := httptest.NewRecorder()
+			rw := newResponseWriter(nil, rec, &ir, nil)
+
+			rw.WriteJSON(tt.resp)
+
+			if got, want := rec.Code, http.StatusOK; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got, want := rec.Header().Get("Content-Type"), "application/json; charset=utf-8"; got != want {
+				t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, want)
+			}
+			if got, want := rec.Header().Get("X-Content-Type-Options"), "nosniff"; got != want {
+				t.Errorf(`rec.Header().Get("X-Content-Type-Options") got: %q want: %q`, got, want)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWriteJSONEncodingError(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var before, commit int
+	ir := newIncomingRequest(httptest.NewRequest("GET", "/", nil))
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, []Interceptor{countingInterceptor{before: &before, commit: &commit}})
+
+	rw.WriteJSON(JSONResponse{Data: map[string]interface{}{"ch": make(chan int)}})
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Body.String(), "Internal Server Error\n"; got != want {
+		t.Errorf("rec.Body got: %q want: %q", got, want)
+	}
+	if commit != 1 {
+		t.Errorf("commit got: %d want: 1", commit)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import "log"
+
+// LogoutConfig configures the handler returned by Logout.
+type LogoutConfig struct {
+	// SessionCookie is the name of the cookie identifying the session.
+	SessionCookie string
+	// Invalidate invalidates the session identified by the value of the
+	// session cookie on the server side. It isn't called for requests
+	// without a session cookie.
+	Invalidate func(r *IncomingRequest, session string) error
+	// Cookies are the names of other cookies to delete, e.g. the cookie
+	// holding the XSRF token.
+	Cookies []string
+	// RedirectURL is the URL the client is redirected to with 303 See
+	// Other. If empty, the handler responds with 204 No Content.
+	RedirectURL string
+}
+
+// Logout returns a handler logging the user out. It invalidates the session,
+// deletes the session cookie and the other configured cookies, and instructs
+// the client to clear the cache, cookies and storage of the origin with the
+// Clear-Site-Data header. The response is not cacheable. If the session can't
+// be invalidated, it responds with 500 Internal Server Error and leaves the
+// cookies untouched.
+//
+// The handler changes state, so it should only be registered for POST
+// requests protected against cross-site request forgery.
+func Logout(cfg LogoutConfig) HandleFunc {
+	return func(w ResponseWriter, r *IncomingRequest) Result {
+		if c, err := r.Cookie(cfg.SessionCookie); err == nil && cfg.Invalidate != nil {
+			if err := cfg.Invalidate(r, c.Value); err != nil {
+				log.Printf("safehttp: invalidating session: %v", err)
+				return w.ServerError(Status500InternalServerError, nil)
+			}
+		}
+		h := w.Header()
+		for _, name := range append([]string{cfg.SessionCookie}, cfg.Cookies...) {
+			if err := h.DeleteCookie(name); err != nil {
+				return w.ServerError(Status500InternalServerError, nil)
+			}
+		}
+		if err := h.Set("Clear-Site-Data", `"cache", "cookies", "storage"`); err != nil {
+			return w.ServerError(Status500InternalServerError, nil)
+		}
+		if err := h.Set("Cache-Control", "no-store"); err != nil {
+			return w.ServerError(Status500InternalServerError, nil)
+		}
+		if cfg.RedirectURL == "" {
+			return w.NoContent()
+		}
+		return w.Redirect(r, cfg.RedirectURL, Status303SeeOther)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLogout(t *testing.T) {
+	var tests = []struct {
+		name            string
+		cookie          string
+		redirect        string
+		invalidateErr   error
+		wantCode        int
+		wantInvalidated []string
+		wantSetCookies  []string
+		wantClear       string
+		wantLocation    string
+	}{
+		{
+			name:            "Session",
+			cookie:          "session=abc; XSRF-TOKEN=t",
+			wantCode:        http.StatusNoContent,
+			wantInvalidated: []string{"abc"},
+			wantSetCookies:  []string{"session=; Path=/; Max-Age=0", "XSRF-TOKEN=; Path=/; Max-Age=0"},
+			wantClear:       `"cache", "cookies", "storage"`,
+		},
+		{
+			name:            "Redirect",
+			cookie:          "session=abc",
+			redirect:        "/login",
+			wantCode:        http.StatusSeeOther,
+			wantInvalidated: []string{"abc"},
+			wantSetCookies:  []string{"session=; Path=/; Max-Age=0", "XSRF-TOKEN=; Path=/; Max-Age=0"},
+			wantClear:       `"cache", "cookies", "storage"`,
+			wantLocation:    "/login",
+		},
+		{
+			name:           "NoSession",
+			wantCode:       http.StatusNoContent,
+			wantSetCookies: []string{"session=; Path=/; Max-Age=0", "XSRF-TOKEN=; Path=/; Max-Age=0"},
+			wantClear:      `"cache", "cookies", "storage"`,
+		},
+		{
+			name:            "InvalidateError",
+			cookie:          "session=abc",
+			invalidateErr:   errors.New("store unavailable"),
+			wantCode:        http.StatusInternalServerError,
+			wantInvalidated: []string{"abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invalidated []string
+			m := NewServeMux(nil)
+			m.Handle("/logout", "POST", Logout(LogoutConfig{
+				SessionCookie: "session",
+				Invalidate: func(r *IncomingRequest, session string) error {
+					invalidated = append(invalidated, session)
+					return tt.invalidateErr
+				},
+				Cookies:     []string{"XSRF-TOKEN"},
+				RedirectURL: tt.redirect,
+			}))
+
+			req := httptest.NewRequest("POST", "/logout", nil)
+			if tt.cookie != "" {
+				req.Header.Set("Cookie", tt.cookie)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if diff := cmp.Diff(tt.wantInvalidated, invalidated); diff != "" {
+				t.Errorf("invalidated sessions mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantSetCookies, rec.Header()["Set-Cookie"]); diff != "" {
+				t.Errorf(`rec.Header()["Set-Cookie"] mismatch (-want +got):\n%s`, diff)
+			}
+			if got := rec.Header().Get("Clear-Site-Data"); got != tt.wantClear {
+				t.Errorf(`rec.Header().Get("Clear-Site-Data") got: %q want: %q`, got, tt.wantClear)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf(`rec.Header().Get("Location") got: %q want: %q`, got, tt.wantLocation)
+			}
+			if tt.wantCode != http.StatusInternalServerError {
+				if got, want := rec.Header().Get("Cache-Control"), "no-store"; got != want {
+					t.Errorf(`rec.Header().Get("Cache-Control") got: %q want: %q`, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ServeMux is an HTTP request multiplexer. It matches the path of each
+// incoming request against the registered patterns, like http.ServeMux, and
+// calls the handler registered for the pattern and the method of the
+// request. The installed interceptors run for every request.
+//
+// Requests with a method that has no registered handler are rejected with
+// 405 Method Not Allowed. OPTIONS requests are answered with 204 No Content
+// and the allowed methods in the Allow header, without invoking any handler,
+// unless an OPTIONS handler is registered for the pattern. A
+// machine-readable capability document can be sent along with them (see
+// SetCapabilities). Note that "OPTIONS *" requests are answered by the
+// http.Server itself.
+//
+// HEAD requests are served by the GET handler, unless a HEAD handler is
+// registered for the pattern. The body written by the GET handler is
+// discarded and its length is sent as Content-Length, so that the headers
+// match the ones of the GET response. HEAD handlers must not write a body:
+// if they do, a 500 Internal Server Error is sent instead.
+type ServeMux struct {
+	d            Dispatcher
+	mux          *http.ServeMux
+	handlers     map[string]map[string]HandleFunc
+	routes       []*paramRoute
+	interceptors []Interceptor
+	configs      map[string][]InterceptorConfig
+	stages       []ResponseStage
+	opts         requestOptions
+	handlerNames bool
+	capabilities CapabilitiesFunc
+}
+
+// NewServeMux creates a ServeMux writing responses with the given
+// Dispatcher.
+func NewServeMux(d Dispatcher) *ServeMux {
+	return &ServeMux{
+		d:        d,
+		mux:      http.NewServeMux(),
+		handlers: map[string]map[string]HandleFunc{},
+	}
+}
+
+// Handle registers the handler for the given pattern and method. It panics
+// if a handler is already registered for them.
+//
+// Patterns can contain path parameters, which match a single non-empty path
+// segment, e.g. "/users/{id}/posts/{postID}". The captured values are
+// available through IncomingRequest.PathParam. Literal patterns take
+// precedence over patterns with parameters and, between patterns with
+// parameters, the one with a literal segment where the other has a parameter
+// wins, e.g. "/users/me" over "/users/{id}". Registering the same method for
+// two patterns with parameters that match the same paths panics.
+//
+// The configs reconfigure or disable installed interceptors for the requests
+// served by the handler (see ConfigurableInterceptor). Handle panics if a
+// config isn't targeted at any installed interceptor, so interceptors must be
+// installed before registering handlers with configs.
+func (m *ServeMux) Handle(pattern, method string, h HandleFunc, cfgs ...InterceptorConfig) {
+	methods, ok := m.handlers[pattern]
+	if !ok {
+		methods = map[string]HandleFunc{}
+	}
+	if _, ok := methods[method]; ok {
+		panic(fmt.Sprintf("safehttp: multiple registrations for %s %s", method, pattern))
+	}
+	for _, cfg := range cfgs {
+		if !targetsAny(m.interceptors, cfg) {
+			panic(fmt.Sprintf("safehttp: no installed interceptor accepts %T config for %s %s", cfg, method, pattern))
+		}
+	}
+	if len(cfgs) > 0 {
+		if m.configs == nil {
+			m.configs = map[string][]InterceptorConfig{}
+		}
+		m.configs[method+" "+pattern] = cfgs
+	}
+	if hasParams(pattern) {
+		m.handleParams(pattern, method, methods)
+	} else if !ok {
+		m.mux.Handle(pattern, methodHandler{m: m, pattern: pattern, methods: methods})
+	}
+	m.handlers[pattern] = methods
+	methods[method] = h
+}
+
+// Install installs the given interceptor. The Before phase of interceptors
+// runs in the order they were installed and the Commit phase in the reverse
+// order, unless they have priorities (see WithPriority).
+func (m *ServeMux) Install(i Interceptor) {
+	m.interceptors = installInterceptor(m.interceptors, i)
+}
+
+// SetCookiePolicy sets the policy enforced on the cookies set by handlers
+// and interceptors through Header.SetCookie.
+func (m *ServeMux) SetCookiePolicy(p CookiePolicy) {
+	m.opts.cookiePolicy = p
+}
+
+// SetRedirectPolicy sets the policy enforced on the redirect targets passed
+// to ResponseWriter.SafeRedirect.
+func (m *ServeMux) SetRedirectPolicy(p RedirectPolicy) {
+	m.opts.redirectPolicy = p
+}
+
+// SetErrorHandler sets the handler writing the error responses of handlers
+// and interceptors, e.g. to render branded error pages. If nil, error
+// responses are written in plain text.
+func (m *ServeMux) SetErrorHandler(h ErrorHandler) {
+	m.opts.errorHandler = h
+}
+
+// RecordHandlerNames configures whether the name of the handler serving a
+// request is recorded in the request context, where it can be retrieved with
+// HandlerName. This is meant to help debugging and should not be enabled in
+// production, as error pages and logs could expose the routing structure.
+func (m *ServeMux) RecordHandlerNames(enabled bool) {
+	m.handlerNames = enabled
+}
+
+// ServeHTTP dispatches the request to the handler registered for its path
+// and method.
+func (m *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h, r := m.handler(r)
+	if len(m.stages) == 0 {
+		h.ServeHTTP(w, r)
+		return
+	}
+	pw := &pipelineWriter{ResponseWriter: w, req: r}
+	h.ServeHTTP(pw, r)
+	pw.flush(m.stages)
+}
+
+type methodHandler struct {
+	m       *ServeMux
+	pattern string
+	methods map[string]HandleFunc
+}
+
+func (mh methodHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	h, ok := mh.methods[method]
+	if !ok && method == http.MethodHead {
+		method = http.MethodGet
+		h, ok = mh.methods[method]
+	}
+	if ok && mh.m.handlerNames {
+		name := method + " " + mh.pattern
+		r = r.WithContext(context.WithValue(r.Context(), handlerNameKey{}, name))
+	}
+	if !ok {
+		methods := mh.allowed()
+		allow := strings.Join(methods, ", ")
+		if r.Method == http.MethodOptions {
+			h = func(w ResponseWriter, r *IncomingRequest) Result {
+				if err := w.Header().Set("Allow", allow); err != nil {
+					return w.ServerError(Status500InternalServerError, nil)
+				}
+				if f := mh.m.capabilities; f != nil {
+					if doc := f(mh.pattern, methods); doc != nil {
+						return w.WriteJSON(JSONResponse{Data: doc, NoPrefix: true})
+					}
+				}
+				return w.NoContent()
+			}
+		} else {
+			h = func(w ResponseWriter, r *IncomingRequest) Result {
+				if err := w.Header().Set("Allow", allow); err != nil {
+					return w.ServerError(Status500InternalServerError, nil)
+				}
+				return w.ClientError(Status405MethodNotAllowed)
+			}
+		}
+	}
+	interceptors := configureInterceptors(mh.m.interceptors, mh.m.configs[method+" "+mh.pattern])
+	_, pipelined := w.(*pipelineWriter)
+	if r.Method != http.MethodHead || (pipelined && method != http.MethodHead) {
+		// A GET handler serving a HEAD request through the response pipeline
+		// writes its body to the pipeline, so that the stages describe it
+		// exactly as they would for a GET request. The pipeline then drops it.
+		handleRequest(h, mh.m.d, interceptors, mh.m.opts, w, r)
+		return
+	}
+	hw := &headWriter{ResponseWriter: w}
+	handleRequest(h, mh.m.d, interceptors, mh.m.opts, hw, r)
+	hw.finish(mh.pattern, method == http.MethodHead)
+}
+
+// has reports whether a handler is registered for the method. HEAD is
+// considered registered if GET is.
+func (mh methodHandler) has(method string) bool {
+	if _, ok := mh.methods[method]; ok {
+		return true
+	}
+	if method == http.MethodHead {
+		return mh.has(http.MethodGet)
+	}
+	return false
+}
+
+// allowed returns the methods listed in the Allow header, i.e. the
+// registered methods, OPTIONS and, if GET is registered, HEAD, sorted.
+func (mh methodHandler) allowed() []string {
+	methods := []string{http.MethodOptions}
+	if _, ok := mh.methods[http.MethodHead]; !ok && mh.has(http.MethodGet) {
+		methods = append(methods, http.MethodHead)
+	}
+	for m := range mh.methods {
+		if m != http.MethodOptions {
+			methods = append(methods, m)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// CapabilitiesFunc describes the route registered for the pattern, whose
+// handlers accept the given methods, in the capability document of OPTIONS
+// responses, e.g. with an OpenAPI path item. It returns nil if the route has
+// no capability document.
+type CapabilitiesFunc func(pattern string, methods []string) interface{}
+
+// SetCapabilities configures the OPTIONS requests without a registered
+// OPTIONS handler to be answered with 200 OK and the capability document of
+// the route, i.e. the value returned by f encoded as JSON, in addition to the
+// Allow header. The document is written without JSONPrefix, as OPTIONS
+// responses can't be loaded by other origins. If f is nil or returns nil,
+// OPTIONS requests are answered with 204 No Content.
+func (m *ServeMux) SetCapabilities(f CapabilitiesFunc) {
+	m.capabilities = f
+}
+
+type handlerNameKey struct{}
+
+// HandlerName returns the name of the handler serving the request, made of
+// its method and pattern, e.g. "GET /users/". It is only available if the
+// ServeMux records handler names and the request was dispatched to a
+// registered handler.
+func HandlerName(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(handlerNameKey{}).(string)
+	return name, ok
+}
@@ -0,0 +1,371 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestServeMuxMethods(t *testing.T) {
+	var tests = []struct {
+		name       string
+		method     string
+		wantCode   int
+		wantAllow  string
+		wantCalled string
+	}{
+		{
+			name:       "Get",
+			method:     "GET",
+			wantCode:   http.StatusNoContent,
+			wantCalled: "GET",
+		},
+		{
+			name:       "Post",
+			method:     "POST",
+			wantCode:   http.StatusNoContent,
+			wantCalled: "POST",
+		},
+		{
+			name:      "Options",
+			method:    "OPTIONS",
+			wantCode:  http.StatusNoContent,
+			wantAllow: "GET, HEAD, OPTIONS, POST",
+		},
+		{
+			name:      "NotAllowed",
+			method:    "DELETE",
+			wantCode:  http.StatusMethodNotAllowed,
+			wantAllow: "GET, HEAD, OPTIONS, POST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called string
+			handler := func(w ResponseWriter, r *IncomingRequest) Result {
+				called = r.Method()
+				return w.NoContent()
+			}
+			m := NewServeMux(nil)
+			m.Handle("/items", "GET", handler)
+			m.Handle("/items", "POST", handler)
+
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(tt.method, "/items", nil))
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf(`rec.Header().Get("Allow") got: %q want: %q`, got, tt.wantAllow)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("called handler got: %q want: %q", called, tt.wantCalled)
+			}
+		})
+	}
+}
+
+func TestServeMuxOptionsHandler(t *testing.T) {
+	m := NewServeMux(nil)
+	m.Handle("/items", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	})
+	m.Handle("/items", "OPTIONS", func(w ResponseWriter, r *IncomingRequest) Result {
+		w.Header().Set("Allow", "custom")
+		return w.NoContent()
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("OPTIONS", "/items", nil))
+
+	if got, want := rec.Header().Get("Allow"), "custom"; got != want {
+		t.Errorf(`rec.Header().Get("Allow") got: %q want: %q`, got, want)
+	}
+}
+
+func TestServeMuxOptionsRunsInterceptors(t *testing.T) {
+	var before, commit int
+	m := NewServeMux(nil)
+	m.Handle("/items", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	})
+	m.Install(countingInterceptor{before: &before, commit: &commit})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("OPTIONS", "/items", nil))
+
+	if before != 1 || commit != 1 {
+		t.Errorf("got before: %d commit: %d want: 1, 1", before, commit)
+	}
+}
+
+func TestServeMuxCapabilities(t *testing.T) {
+	type doc struct {
+		Path    string   `json:"path"`
+		Methods []string `json:"methods"`
+	}
+	var tests = []struct {
+		name         string
+		capabilities CapabilitiesFunc
+		wantCode     int
+		wantType     string
+		wantBody     string
+	}{
+		{
+			name:     "NotConfigured",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name: "Document",
+			capabilities: func(pattern string, methods []string) interface{} {
+				return doc{Path: pattern, Methods: methods}
+			},
+			wantCode: http.StatusOK,
+			wantType: "application/json; charset=utf-8",
+			wantBody: `{"path":"/items","methods":["GET","HEAD","OPTIONS","POST"]}`,
+		},
+		{
+			name: "NoDocument",
+			capabilities: func(pattern string, methods []string) interface{} {
+				return nil
+			},
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			handler := func(w ResponseWriter, r *IncomingRequest) Result {
+				called = true
+				return w.NoContent()
+			}
+			m := NewServeMux(nil)
+			m.Handle("/items", "GET", handler)
+			m.Handle("/items", "POST", handler)
+			m.SetCapabilities(tt.capabilities)
+
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest("OPTIONS", "/items", nil))
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got, want := rec.Header().Get("Allow"), "GET, HEAD, OPTIONS, POST"; got != want {
+				t.Errorf(`rec.Header().Get("Allow") got: %q want: %q`, got, want)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, tt.wantType)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+			if called {
+				t.Error("handler called, want not called")
+			}
+		})
+	}
+}
+
+func TestServeMuxDuplicateRegistration(t *testing.T) {
+	m := NewServeMux(nil)
+	h := func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	}
+	m.Handle("/items", "GET", h)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error(`m.Handle("/items", "GET", h) expected panic`)
+		}
+	}()
+	m.Handle("/items", "GET", h)
+}
+
+func TestServeMuxHandlerName(t *testing.T) {
+	var tests = []struct {
+		name     string
+		record   bool
+		wantName string
+		wantOK   bool
+	}{
+		{
+			name:     "Recorded",
+			record:   true,
+			wantName: "GET /users/",
+			wantOK:   true,
+		},
+		{
+			name:   "NotRecorded",
+			record: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotName string
+			var gotOK bool
+			m := NewServeMux(nil)
+			m.Handle("/users/", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+				gotName, gotOK = HandlerName(r.Context())
+				return w.NoContent()
+			})
+			m.RecordHandlerNames(tt.record)
+
+			m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+			if gotName != tt.wantName || gotOK != tt.wantOK {
+				t.Errorf("HandlerName() got: %q, %v want: %q, %v", gotName, gotOK, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestServeMuxCookiePolicy(t *testing.T) {
+	var gotErr error
+	m := NewServeMux(nil)
+	m.Handle("/", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		gotErr = w.Header().SetCookie(&http.Cookie{Name: "session", Value: "abc", SameSite: http.SameSiteLaxMode})
+		return w.NoContent()
+	})
+	m.SetCookiePolicy(CookiePolicy{RequireSameSite: true, RequireSecure: true})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if !errors.Is(gotErr, ErrCookiePolicy) {
+		t.Errorf("SetCookie() got err: %v want: ErrCookiePolicy", gotErr)
+	}
+	if got := rec.Header().Values("Set-Cookie"); len(got) != 0 {
+		t.Errorf(`rec.Header().Values("Set-Cookie") got: %v want: none`, got)
+	}
+}
+
+type skipConfig struct{}
+
+type renameConfig struct {
+	name string
+}
+
+type configurableInterceptor struct {
+	recordingInterceptor
+}
+
+func (it configurableInterceptor) Configure(cfg InterceptorConfig) (Interceptor, bool) {
+	switch c := cfg.(type) {
+	case skipConfig:
+		return nil, true
+	case renameConfig:
+		it.name = c.name
+		return it, true
+	}
+	return nil, false
+}
+
+func TestServeMuxInterceptorConfig(t *testing.T) {
+	var tests = []struct {
+		name      string
+		configs   []InterceptorConfig
+		wrap      func(Interceptor) Interceptor
+		wantCalls []string
+	}{
+		{
+			name:      "NoConfig",
+			wantCalls: []string{"Before a", "Commit a"},
+		},
+		{
+			name:    "Skip",
+			configs: []InterceptorConfig{skipConfig{}},
+		},
+		{
+			name:      "Reconfigure",
+			configs:   []InterceptorConfig{renameConfig{name: "b"}},
+			wantCalls: []string{"Before b", "Commit b"},
+		},
+		{
+			name:    "SkipWrapped",
+			configs: []InterceptorConfig{skipConfig{}},
+			wrap: func(i Interceptor) Interceptor {
+				return WithPriority(WithToggle(i, &Toggle{}), 1)
+			},
+		},
+		{
+			name:    "ReconfigureWrapped",
+			configs: []InterceptorConfig{renameConfig{name: "b"}},
+			wrap: func(i Interceptor) Interceptor {
+				return WithToggle(WithPriority(i, 1), &Toggle{})
+			},
+			wantCalls: []string{"Before b", "Commit b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			var it Interceptor = configurableInterceptor{recordingInterceptor{name: "a", calls: &calls}}
+			if tt.wrap != nil {
+				it = tt.wrap(it)
+			}
+			m := NewServeMux(nil)
+			m.Install(it)
+			m.Handle("/webhook", "POST", func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.NoContent()
+			}, tt.configs...)
+
+			m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/webhook", nil))
+
+			if diff := cmp.Diff(tt.wantCalls, calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServeMuxInterceptorConfigOtherRoutes(t *testing.T) {
+	var calls []string
+	m := NewServeMux(nil)
+	m.Install(configurableInterceptor{recordingInterceptor{name: "a", calls: &calls}})
+	h := func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	}
+	m.Handle("/webhook", "POST", h, skipConfig{})
+	m.Handle("/webhook", "GET", h)
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/webhook", nil))
+
+	want := []string{"Before a", "Commit a"}
+	if diff := cmp.Diff(want, calls); diff != "" {
+		t.Errorf("calls mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestServeMuxUnknownInterceptorConfig(t *testing.T) {
+	var calls []string
+	m := NewServeMux(nil)
+	m.Install(recordingInterceptor{name: "a", calls: &calls})
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error(`m.Handle("/webhook", "POST", h, skipConfig{}) expected panic`)
+		}
+	}()
+	m.Handle("/webhook", "POST", func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	}, skipConfig{})
+}
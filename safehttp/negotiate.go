@@ -0,0 +1,225 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// weightedValue is an element of a header like Accept, together with its
+// quality value.
+type weightedValue struct {
+	value string
+	q     float64
+}
+
+// parseWeighted parses the comma-separated elements of the given header
+// values and their quality values, as defined in RFC 7231, Section 5.3.1.
+// Values are lowercased. Elements with an invalid quality value are
+// ignored.
+func parseWeighted(values []string) []weightedValue {
+	var res []weightedValue
+	for _, v := range values {
+		for _, e := range strings.Split(v, ",") {
+			params := strings.Split(e, ";")
+			wv := weightedValue{value: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+			if wv.value == "" {
+				continue
+			}
+			valid := true
+			for _, p := range params[1:] {
+				p = strings.TrimSpace(p)
+				if !strings.HasPrefix(p, "q=") && !strings.HasPrefix(p, "Q=") {
+					continue
+				}
+				q, err := strconv.ParseFloat(p[2:], 64)
+				if err != nil || q < 0 || q > 1 {
+					valid = false
+					break
+				}
+				wv.q = q
+			}
+			if valid {
+				res = append(res, wv)
+			}
+		}
+	}
+	return res
+}
+
+// Negotiate returns the offered media type preferred by the client, based on
+// the Accept headers of the request, as defined in RFC 7231, Section 5.3.2.
+// The most specific media range matching an offer determines its quality.
+// Ties are resolved in favor of the offer listed first. If the request has no
+// Accept header, the first offer is returned. If no offer is acceptable, ""
+// is returned and the request should be rejected with 406 Not Acceptable. It
+// panics if no offer is given.
+//
+// As the response depends on the Accept header, it is added to the Vary
+// header of w. If the Vary header is immutable, the media type isn't
+// negotiated: the first offer is returned, as if the request had no Accept
+// header.
+func Negotiate(w ResponseWriter, r *IncomingRequest, offers ...string) string {
+	if len(offers) == 0 {
+		panic("no media type offered")
+	}
+	if err := w.Header().AddVary("Accept"); err != nil {
+		return offers[0]
+	}
+	return negotiateMediaType(r.Header.Values("Accept"), offers...)
+}
+
+// negotiateMediaType returns the offered media type preferred by the client,
+// based on the given Accept header values. The most specific media range
+// matching an offer determines its quality. Ties are resolved in favor of the
+// offer listed first. If no Accept header was sent, the first offer is
+// returned. If no offer is acceptable, "" is returned.
+func negotiateMediaType(accept []string, offers ...string) string {
+	if len(accept) == 0 {
+		return offers[0]
+	}
+	ranges := parseWeighted(accept)
+	best, bestQ := "", 0.0
+	for _, o := range offers {
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			s := mediaRangeSpecificity(r.value, o)
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = o, q
+		}
+	}
+	return best
+}
+
+// mediaRangeSpecificity returns how specifically the media range matches the
+// media type: 2 for an exact match, 1 for type/* and 0 for */*. It returns -1
+// if the range doesn't match.
+func mediaRangeSpecificity(mediaRange, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
+
+// NegotiateEncoding selects the content coding of the response among the
+// supported ones, based on the Accept-Encoding headers of the request, as
+// defined in RFC 7231, Section 5.3.4.
+//
+// Codings not listed by the client get the quality of "*", if present, and
+// are not acceptable otherwise. The identity coding (no compression) is
+// acceptable unless it is explicitly excluded with "identity;q=0", or with
+// "*;q=0" and no identity entry. Among the acceptable codings, the one with
+// the highest quality is selected; ties are resolved in the order of
+// supported. Identity is selected if it has a higher quality than all the
+// supported codings or, when not listed, if none of them is acceptable. If no
+// coding is acceptable, ok is false and the request should be rejected with
+// 406 Not Acceptable.
+//
+// As the response depends on the Accept-Encoding header, it is added to the
+// Vary header of w. If the Vary header is immutable, the coding isn't
+// negotiated: identity is selected, as if the request had no Accept-Encoding
+// header.
+func NegotiateEncoding(w ResponseWriter, r *IncomingRequest, supported ...string) (encoding string, ok bool) {
+	if err := w.Header().AddVary("Accept-Encoding"); err != nil {
+		return "identity", true
+	}
+	return negotiateEncoding(r.Header.Values("Accept-Encoding"), supported...)
+}
+
+// negotiateEncoding selects the content coding among the supported ones,
+// based on the given Accept-Encoding header values, like NegotiateEncoding.
+func negotiateEncoding(accept []string, supported ...string) (encoding string, ok bool) {
+	if len(accept) == 0 {
+		return "identity", true
+	}
+	codings := parseWeighted(accept)
+	quality := func(coding string) (float64, bool) {
+		q, found := 0.0, false
+		for _, c := range codings {
+			if c.value == coding {
+				return c.q, true
+			}
+			if c.value == "*" {
+				q, found = c.q, true
+			}
+		}
+		return q, found
+	}
+
+	best, bestQ := "", 0.0
+	for _, s := range supported {
+		if q, _ := quality(strings.ToLower(s)); q > bestQ {
+			best, bestQ = s, q
+		}
+	}
+	identityQ, found := quality("identity")
+	if !found {
+		// Identity is implicitly acceptable, but only as a fallback.
+		identityQ = 1
+		if best != "" {
+			identityQ = 0
+		}
+	}
+	if identityQ > bestQ {
+		return "identity", true
+	}
+	return best, best != ""
+}
+
+// AcceptsCharset reports whether the given charset is acceptable according to
+// the Accept-Charset headers of the request, as defined in RFC 7231, Section
+// 5.3.3. Charsets are compared case-insensitively. A charset not listed by
+// the client gets the quality of "*", if present, and is not acceptable
+// otherwise. Any charset is acceptable if the request has no Accept-Charset
+// header.
+//
+// As the response depends on the Accept-Charset header, it is added to the
+// Vary header of w. If the Vary header is immutable, any charset is
+// acceptable, as if the request had no Accept-Charset header.
+func AcceptsCharset(w ResponseWriter, r *IncomingRequest, charset string) bool {
+	if err := w.Header().AddVary("Accept-Charset"); err != nil {
+		return true
+	}
+	return acceptsCharset(r.Header.Values("Accept-Charset"), charset)
+}
+
+// acceptsCharset reports whether the charset is acceptable according to the
+// given Accept-Charset header values, like AcceptsCharset.
+func acceptsCharset(accept []string, charset string) bool {
+	if len(accept) == 0 {
+		return true
+	}
+	charset = strings.ToLower(charset)
+	q := 0.0
+	for _, c := range parseWeighted(accept) {
+		if c.value == charset {
+			return c.q > 0
+		}
+		if c.value == "*" {
+			q = c.q
+		}
+	}
+	return q > 0
+}
@@ -0,0 +1,226 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	var tests = []struct {
+		name   string
+		accept string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "NoHeader",
+			want:   "identity",
+			wantOK: true,
+		},
+		{
+			name:   "GzipProhibited",
+			accept: "gzip;q=0, br",
+			want:   "br",
+			wantOK: true,
+		},
+		{
+			name:   "HighestQuality",
+			accept: "gzip;q=0.5, br;q=0.8",
+			want:   "br",
+			wantOK: true,
+		},
+		{
+			name:   "TieUsesServerPreference",
+			accept: "br, gzip",
+			want:   "gzip",
+			wantOK: true,
+		},
+		{
+			name:   "IdentityProhibited",
+			accept: "identity;q=0",
+			wantOK: false,
+		},
+		{
+			name:   "IdentityProhibitedGzipAllowed",
+			accept: "identity;q=0, gzip;q=0.1",
+			want:   "gzip",
+			wantOK: true,
+		},
+		{
+			name:   "Wildcard",
+			accept: "*",
+			want:   "gzip",
+			wantOK: true,
+		},
+		{
+			name:   "WildcardProhibited",
+			accept: "*;q=0",
+			wantOK: false,
+		},
+		{
+			name:   "WildcardProhibitedIdentityAllowed",
+			accept: "*;q=0, identity",
+			want:   "identity",
+			wantOK: true,
+		},
+		{
+			name:   "UnsupportedOnly",
+			accept: "zstd",
+			want:   "identity",
+			wantOK: true,
+		},
+		{
+			name:   "CaseInsensitive",
+			accept: "GZIP",
+			want:   "gzip",
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			ir := newIncomingRequest(req)
+			rec := httptest.NewRecorder()
+			rw := newResponseWriter(nil, rec, &ir, nil)
+			got, ok := NegotiateEncoding(rw, &ir, "gzip", "br")
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("NegotiateEncoding() got: (%q, %v) want: (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+			if got, want := rec.Header().Get("Vary"), "Accept-Encoding"; got != want {
+				t.Errorf(`rec.Header().Get("Vary") got: %q want: %q`, got, want)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html;q=0.5, application/json")
+	ir := newIncomingRequest(req)
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+
+	if got, want := Negotiate(rw, &ir, "text/html", "application/json"), "application/json"; got != want {
+		t.Errorf("Negotiate() got: %q want: %q", got, want)
+	}
+	if got, want := rec.Header().Get("Vary"), "Accept"; got != want {
+		t.Errorf(`rec.Header().Get("Vary") got: %q want: %q`, got, want)
+	}
+}
+
+func TestNegotiateImmutableVary(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Accept-Charset", "iso-8859-1")
+	ir := newIncomingRequest(req)
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+	rw.Header().MarkImmutable("Vary")
+
+	if got, want := Negotiate(rw, &ir, "text/html", "application/json"), "text/html"; got != want {
+		t.Errorf("Negotiate() got: %q want: %q", got, want)
+	}
+	if got, ok := NegotiateEncoding(rw, &ir, "gzip"); got != "identity" || !ok {
+		t.Errorf(`NegotiateEncoding() got: (%q, %v) want: ("identity", true)`, got, ok)
+	}
+	if !AcceptsCharset(rw, &ir, "utf-8") {
+		t.Error(`AcceptsCharset(rw, &ir, "utf-8") got: false want: true`)
+	}
+	if got := rec.Header().Get("Vary"); got != "" {
+		t.Errorf(`rec.Header().Get("Vary") got: %q want: ""`, got)
+	}
+}
+
+func TestNegotiateMediaType(t *testing.T) {
+	var tests = []struct {
+		name   string
+		accept []string
+		want   string
+	}{
+		{
+			name: "NoHeader",
+			want: "application/json",
+		},
+		{
+			name:   "Exact",
+			accept: []string{"text/html"},
+			want:   "text/html",
+		},
+		{
+			name:   "MostSpecificRangeWins",
+			accept: []string{"text/*;q=0.1, text/html;q=0.9, application/*;q=0.5"},
+			want:   "text/html",
+		},
+		{
+			name:   "WildcardTie",
+			accept: []string{"*/*"},
+			want:   "application/json",
+		},
+		{
+			name:   "NoneAcceptable",
+			accept: []string{"image/png"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateMediaType(tt.accept, "application/json", "text/html"); got != tt.want {
+				t.Errorf("negotiateMediaType(%q) got: %q want: %q", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptsCharset(t *testing.T) {
+	var tests = []struct {
+		name   string
+		accept string
+		want   bool
+	}{
+		{name: "NoHeader", want: true},
+		{name: "UTF8", accept: "utf-8", want: true},
+		{name: "CaseInsensitive", accept: "UTF-8;q=0.5", want: true},
+		{name: "Wildcard", accept: "iso-8859-1, *;q=0.1", want: true},
+		{name: "NotListed", accept: "iso-8859-1", want: false},
+		{name: "Excluded", accept: "utf-8;q=0, *", want: false},
+		{name: "WildcardExcluded", accept: "*;q=0", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Charset", tt.accept)
+			}
+			ir := newIncomingRequest(req)
+			rec := httptest.NewRecorder()
+			rw := newResponseWriter(nil, rec, &ir, nil)
+			if got := AcceptsCharset(rw, &ir, "utf-8"); got != tt.want {
+				t.Errorf(`AcceptsCharset(%q, "utf-8") got: %v want: %v`, tt.accept, got, tt.want)
+			}
+			if got, want := rec.Header().Get("Vary"), "Accept-Charset"; got != want {
+				t.Errorf(`rec.Header().Get("Vary") got: %q want: %q`, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// paramRoute is a registered pattern with path parameters, like
+// "/users/{id}".
+type paramRoute struct {
+	pattern string
+	// segments are the segments of the pattern, separated by slashes. A
+	// segment is either a literal or a parameter name in braces.
+	segments []string
+	handler  methodHandler
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// hasParams reports whether the pattern contains path parameters.
+func hasParams(pattern string) bool {
+	return strings.Contains(pattern, "{")
+}
+
+// parsePattern splits a pattern with path parameters into segments. It
+// panics if the pattern is malformed.
+func parsePattern(pattern string) []string {
+	if !strings.HasPrefix(pattern, "/") {
+		panic(fmt.Sprintf("safehttp: pattern %q with path parameters must start with /", pattern))
+	}
+	segments := strings.Split(pattern[1:], "/")
+	names := map[string]bool{}
+	for _, s := range segments {
+		if !strings.ContainsAny(s, "{}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+		if !isParam(s) || name == "" || strings.ContainsAny(name, "{}") {
+			panic(fmt.Sprintf("safehttp: malformed path parameter %q in pattern %q", s, pattern))
+		}
+		if names[name] {
+			panic(fmt.Sprintf("safehttp: duplicate path parameter %q in pattern %q", name, pattern))
+		}
+		names[name] = true
+	}
+	return segments
+}
+
+// sameShape reports whether both routes match exactly the same paths.
+func (rt *paramRoute) sameShape(other *paramRoute) bool {
+	if len(rt.segments) != len(other.segments) {
+		return false
+	}
+	for i, s := range rt.segments {
+		o := other.segments[i]
+		if isParam(s) != isParam(o) || (!isParam(s) && s != o) {
+			return false
+		}
+	}
+	return true
+}
+
+// match matches the decoded path segments against the route and returns the
+// captured parameters.
+func (rt *paramRoute) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, s := range rt.segments {
+		switch {
+		case isParam(s):
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[s[1:len(s)-1]] = segments[i]
+		case s != segments[i]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// moreSpecific reports whether the route takes precedence over the other
+// one, i.e. whether it has a literal segment where the other one has a
+// parameter first.
+func (rt *paramRoute) moreSpecific(other *paramRoute) bool {
+	for i, s := range rt.segments {
+		if p, o := isParam(s), isParam(other.segments[i]); p != o {
+			return o
+		}
+	}
+	return false
+}
+
+// handleParams registers the handler for a pattern with path parameters. It
+// panics if another pattern matching the same paths has a handler for the
+// method.
+func (m *ServeMux) handleParams(pattern, method string, methods map[string]HandleFunc) {
+	var route *paramRoute
+	for _, rt := range m.routes {
+		if rt.pattern == pattern {
+			route = rt
+		}
+	}
+	if route == nil {
+		route = &paramRoute{
+			pattern:  pattern,
+			segments: parsePattern(pattern),
+			handler:  methodHandler{m: m, pattern: pattern, methods: methods},
+		}
+		m.routes = append(m.routes, route)
+	}
+	for _, rt := range m.routes {
+		if rt == route || !rt.sameShape(route) {
+			continue
+		}
+		if _, ok := rt.handler.methods[method]; ok {
+			panic(fmt.Sprintf("safehttp: conflicting registrations for %s %s and %s", method, rt.pattern, pattern))
+		}
+	}
+}
+
+// matchParams returns the handler of the most specific route with path
+// parameters matching the request path, with the request carrying the
+// captured parameters.
+func (m *ServeMux) matchParams(r *http.Request) (http.Handler, *http.Request) {
+	if len(m.routes) == 0 {
+		return nil, r
+	}
+	var segments []string
+	for _, s := range strings.Split(r.URL.EscapedPath()[1:], "/") {
+		// Segments are decoded individually, so that an encoded slash
+		// doesn't split a parameter value.
+		d, err := url.PathUnescape(s)
+		if err != nil {
+			return nil, r
+		}
+		segments = append(segments, d)
+	}
+	var best *paramRoute
+	var bestParams map[string]string
+	for _, rt := range m.routes {
+		params, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		// Between routes matching the same paths, the one with a handler
+		// for the method wins.
+		if best == nil || rt.moreSpecific(best) ||
+			(rt.sameShape(best) && !best.handler.has(r.Method) && rt.handler.has(r.Method)) {
+			best, bestParams = rt, params
+		}
+	}
+	if best == nil {
+		return nil, r
+	}
+	return best.handler, r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, bestParams))
+}
+
+// handler returns the handler for the request. Exact matches of literal
+// patterns take precedence over patterns with path parameters, which take
+// precedence over the prefix matches of patterns ending with a slash.
+func (m *ServeMux) handler(r *http.Request) (http.Handler, *http.Request) {
+	p := r.URL.Path
+	if !strings.HasPrefix(p, "/") || path.Clean(p) != p && !(strings.HasSuffix(p, "/") && path.Clean(p)+"/" == p) {
+		// Let http.ServeMux redirect to the clean path.
+		return m.mux, r
+	}
+	if _, pattern := m.mux.Handler(r); pattern != "" {
+		if i := strings.Index(pattern, "/"); i >= 0 && (pattern[i:] == p || !strings.HasSuffix(pattern, "/")) {
+			return m.mux, r
+		}
+	}
+	if h, pr := m.matchParams(r); h != nil {
+		return h, pr
+	}
+	return m.mux, r
+}
+
+type pathParamsKey struct{}
+
+// PathParam returns the value of the path parameter with the given name,
+// captured from the request path by a ServeMux pattern like "/users/{id}".
+// The value is URL-decoded. It returns "" if there is no such parameter.
+func (r *IncomingRequest) PathParam(name string) string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params[name]
+}
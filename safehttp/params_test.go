@@ -0,0 +1,215 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestServeMuxPathParams(t *testing.T) {
+	var tests = []struct {
+		name       string
+		path       string
+		wantCode   int
+		wantRoute  string
+		wantParams map[string]string
+	}{
+		{
+			name:       "Params",
+			path:       "/users/42/posts/7",
+			wantCode:   http.StatusNoContent,
+			wantRoute:  "/users/{id}/posts/{postID}",
+			wantParams: map[string]string{"id": "42", "postID": "7"},
+		},
+		{
+			name:       "Decoded",
+			path:       "/users/a%20b/posts/7",
+			wantCode:   http.StatusNoContent,
+			wantRoute:  "/users/{id}/posts/{postID}",
+			wantParams: map[string]string{"id": "a b", "postID": "7"},
+		},
+		{
+			name:       "EncodedSlash",
+			path:       "/users/a%2Fb/posts/7",
+			wantCode:   http.StatusNoContent,
+			wantRoute:  "/users/{id}/posts/{postID}",
+			wantParams: map[string]string{"id": "a/b", "postID": "7"},
+		},
+		{
+			name:       "DecodedOnce",
+			path:       "/users/a%252Fb/posts/7",
+			wantCode:   http.StatusNoContent,
+			wantRoute:  "/users/{id}/posts/{postID}",
+			wantParams: map[string]string{"id": "a%2Fb", "postID": "7"},
+		},
+		{
+			name:     "NoMatchAcrossSlash",
+			path:     "/users/a/b/posts/7",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:       "LiteralWins",
+			path:       "/users/me",
+			wantCode:   http.StatusNoContent,
+			wantRoute:  "/users/me",
+			wantParams: map[string]string{},
+		},
+		{
+			name:       "Param",
+			path:       "/users/42",
+			wantCode:   http.StatusNoContent,
+			wantRoute:  "/users/{id}",
+			wantParams: map[string]string{"id": "42"},
+		},
+		{
+			name:       "LiteralSegmentWins",
+			path:       "/users/42/posts/latest",
+			wantCode:   http.StatusNoContent,
+			wantRoute:  "/users/{id}/posts/latest",
+			wantParams: map[string]string{"id": "42"},
+		},
+		{
+			name:       "ParamWinsOverPrefix",
+			path:       "/files/report.pdf",
+			wantCode:   http.StatusNoContent,
+			wantRoute:  "/files/{name}",
+			wantParams: map[string]string{"name": "report.pdf"},
+		},
+		{
+			name:       "Prefix",
+			path:       "/files/a/b",
+			wantCode:   http.StatusNoContent,
+			wantRoute:  "/files/",
+			wantParams: map[string]string{},
+		},
+	}
+
+	var gotRoute string
+	var gotParams map[string]string
+	m := NewServeMux(nil)
+	for _, p := range []string{
+		"/users/{id}/posts/{postID}",
+		"/users/{id}/posts/latest",
+		"/users/me",
+		"/users/{id}",
+		"/files/",
+		"/files/{name}",
+	} {
+		p := p
+		m.Handle(p, "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+			gotRoute = p
+			gotParams = map[string]string{}
+			for _, name := range []string{"id", "postID", "name"} {
+				if v := r.PathParam(name); v != "" {
+					gotParams[name] = v
+				}
+			}
+			return w.NoContent()
+		})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRoute, gotParams = "", nil
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+			if gotRoute != tt.wantRoute {
+				t.Errorf("route got: %q want: %q", gotRoute, tt.wantRoute)
+			}
+			if diff := cmp.Diff(tt.wantParams, gotParams); diff != "" {
+				t.Errorf("path params mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServeMuxPathParamsMethods(t *testing.T) {
+	h := func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	}
+	m := NewServeMux(nil)
+	m.Handle("/items/{id}", "GET", h)
+	m.Handle("/items/{id}", "DELETE", h)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("POST", "/items/1", nil))
+	if got, want := rec.Code, http.StatusMethodNotAllowed; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Allow"), "DELETE, GET, HEAD, OPTIONS"; got != want {
+		t.Errorf(`rec.Header().Get("Allow") got: %q want: %q`, got, want)
+	}
+}
+
+func TestServeMuxPathParamsSameShape(t *testing.T) {
+	var got string
+	m := NewServeMux(nil)
+	m.Handle("/items/{id}", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		got = "GET " + r.PathParam("id")
+		return w.NoContent()
+	})
+	m.Handle("/items/{itemID}", "DELETE", func(w ResponseWriter, r *IncomingRequest) Result {
+		got = "DELETE " + r.PathParam("itemID")
+		return w.NoContent()
+	})
+
+	for _, want := range []string{"GET 1", "DELETE 2"} {
+		got = ""
+		method, id := want[:len(want)-2], want[len(want)-1:]
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/items/"+id, nil))
+		if got != want {
+			t.Errorf("handler got: %q want: %q", got, want)
+		}
+	}
+}
+
+func TestServeMuxPathParamsPanics(t *testing.T) {
+	h := func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	}
+	var tests = []struct {
+		name     string
+		patterns []string
+	}{
+		{name: "Conflict", patterns: []string{"/users/{id}", "/users/{uid}"}},
+		{name: "Duplicate", patterns: []string{"/users/{id}", "/users/{id}"}},
+		{name: "Malformed", patterns: []string{"/users/{id"}},
+		{name: "PartialSegment", patterns: []string{"/users/id-{id}"}},
+		{name: "DuplicateName", patterns: []string{"/users/{id}/posts/{id}"}},
+		{name: "Relative", patterns: []string{"users/{id}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("m.Handle(%q) expected panic", tt.patterns)
+				}
+			}()
+			m := NewServeMux(nil)
+			for _, p := range tt.patterns {
+				m.Handle(p, "GET", h)
+			}
+		})
+	}
+}
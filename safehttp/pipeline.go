@@ -0,0 +1,173 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ResponseBody is the body of a response passing through the response
+// pipeline of a ServeMux, together with the headers describing it.
+type ResponseBody struct {
+	ContentType     string
+	ContentEncoding string
+	Data            []byte
+	// Request is the request the response is sent for, e.g. to negotiate the
+	// content coding. Stages must not modify it.
+	Request *http.Request
+
+	// header holds the other headers of the response. Stages can only access
+	// them through the methods of ResponseBody, so that the headers made
+	// immutable by interceptors stay untouched.
+	header Header
+}
+
+// AddVary adds the given request header names to the Vary header of the
+// response, like Header.AddVary. It returns an error if the Vary header is
+// immutable or if the body isn't part of a response.
+func (b ResponseBody) AddVary(names ...string) error {
+	if b.header.wrapped == nil {
+		return errors.New("not the body of a response")
+	}
+	return b.header.AddVary(names...)
+}
+
+// NegotiateEncoding selects the content coding of the response among the
+// supported ones, based on the Accept-Encoding headers of the Request, like
+// the NegotiateEncoding function. Accept-Encoding is added to the Vary
+// header of the response. If that fails, or if the body isn't part of a
+// response, identity is selected.
+func (b ResponseBody) NegotiateEncoding(supported ...string) (encoding string, ok bool) {
+	if b.Request == nil || b.AddVary("Accept-Encoding") != nil {
+		return "identity", true
+	}
+	return negotiateEncoding(b.Request.Header.Values("Accept-Encoding"), supported...)
+}
+
+// CacheControl returns the value of the Cache-Control header of the
+// response, e.g. to leave the bodies with the no-transform directive alone.
+func (b ResponseBody) CacheControl() string {
+	if b.header.wrapped == nil {
+		return ""
+	}
+	return b.header.Get("Cache-Control")
+}
+
+// SetHeader sets a header of the response, like Header.Set, e.g. to describe
+// the final body. It returns an error if the header is immutable or
+// Set-Cookie, or if the body isn't part of a response.
+func (b ResponseBody) SetHeader(name, value string) error {
+	if b.header.wrapped == nil {
+		return errors.New("not the body of a response")
+	}
+	return b.header.Set(name, value)
+}
+
+// ResponseStage is a stage of the response pipeline of a ServeMux, e.g.
+// minifying or compressing response bodies. Stages that don't apply to a
+// body, e.g. because of its content type, should return it unchanged.
+type ResponseStage interface {
+	Transform(b ResponseBody) (ResponseBody, error)
+}
+
+// AddResponseStage appends a stage to the response pipeline. When stages are
+// added, response bodies are buffered and passed through the stages in the
+// order they were added before being sent. The Content-Type,
+// Content-Encoding and Content-Length headers are then set to describe the
+// final body. If a stage fails, a 500 Internal Server Error is sent instead.
+//
+// Responses without a body, e.g. 204 No Content, don't go through the
+// pipeline. The body written by a GET handler serving a HEAD request goes
+// through the stages like that of the GET request, so that both get the same
+// headers, and is dropped afterwards.
+func (m *ServeMux) AddResponseStage(s ResponseStage) {
+	m.stages = append(m.stages, s)
+}
+
+// pipelineWriter buffers the response so that it can be passed through the
+// response pipeline once the handler returns.
+type pipelineWriter struct {
+	http.ResponseWriter
+	req *http.Request
+	// header is the Header of the ResponseWriter writing the response, which
+	// tracks the immutable headers.
+	header Header
+	code   int
+	buf    bytes.Buffer
+}
+
+func (pw *pipelineWriter) WriteHeader(code int) {
+	if pw.code == 0 {
+		pw.code = code
+	}
+}
+
+func (pw *pipelineWriter) Write(b []byte) (int, error) {
+	pw.WriteHeader(http.StatusOK)
+	return pw.buf.Write(b)
+}
+
+// flush passes the buffered body through the stages and writes the result to
+// the underlying http.ResponseWriter.
+func (pw *pipelineWriter) flush(stages []ResponseStage) {
+	if pw.code == 0 {
+		pw.code = http.StatusOK
+	}
+	if pw.buf.Len() == 0 {
+		pw.ResponseWriter.WriteHeader(pw.code)
+		return
+	}
+
+	h := pw.Header()
+	header := pw.header
+	if header.wrapped == nil {
+		// The response wasn't written through a ResponseWriter, e.g. a 404
+		// Not Found written by the http.ServeMux.
+		header = newHeader(h)
+	}
+	b := ResponseBody{
+		ContentType:     h.Get("Content-Type"),
+		ContentEncoding: h.Get("Content-Encoding"),
+		Data:            pw.buf.Bytes(),
+		Request:         pw.req,
+		header:          header,
+	}
+	for _, s := range stages {
+		var err error
+		if b, err = s.Transform(b); err != nil {
+			h.Del("Content-Encoding")
+			http.Error(pw.ResponseWriter, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	setOrDel := func(name, value string) {
+		if value == "" {
+			h.Del(name)
+		} else {
+			h.Set(name, value)
+		}
+	}
+	setOrDel("Content-Type", b.ContentType)
+	setOrDel("Content-Encoding", b.ContentEncoding)
+	h.Set("Content-Length", strconv.Itoa(len(b.Data)))
+	pw.ResponseWriter.WriteHeader(pw.code)
+	if pw.req.Method != http.MethodHead {
+		pw.ResponseWriter.Write(b.Data)
+	}
+}
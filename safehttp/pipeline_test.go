@@ -0,0 +1,184 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// minifier collapses runs of whitespace in text/plain bodies.
+type minifier struct{}
+
+func (minifier) Transform(b ResponseBody) (ResponseBody, error) {
+	if !strings.HasPrefix(b.ContentType, "text/plain") {
+		return b, nil
+	}
+	b.Data = []byte(strings.Join(strings.Fields(string(b.Data)), " "))
+	return b, nil
+}
+
+type gzipper struct{}
+
+func (gzipper) Transform(b ResponseBody) (ResponseBody, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(b.Data)
+	if err := zw.Close(); err != nil {
+		return ResponseBody{}, err
+	}
+	b.ContentEncoding = "gzip"
+	b.Data = buf.Bytes()
+	return b, nil
+}
+
+type failingStage struct{}
+
+func (failingStage) Transform(b ResponseBody) (ResponseBody, error) {
+	return ResponseBody{}, errors.New("failed")
+}
+
+func TestResponsePipeline(t *testing.T) {
+	m := NewServeMux(nil)
+	m.Handle("/", "GET", TextFile("hello   \n  world\n", time.Hour))
+	m.AddResponseStage(minifier{})
+	m.AddResponseStage(gzipper{})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Content-Encoding"), "gzip"; got != want {
+		t.Errorf(`rec.Header().Get("Content-Encoding") got: %q want: %q`, got, want)
+	}
+	if got, want := rec.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+		t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, want)
+	}
+	if got, want := rec.Header().Get("Content-Length"), strconv.Itoa(rec.Body.Len()); got != want {
+		t.Errorf(`rec.Header().Get("Content-Length") got: %q want: %q`, got, want)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() got err: %v", err)
+	}
+	body, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("ioutil.ReadAll() got err: %v", err)
+	}
+	if got, want := string(body), "hello world"; got != want {
+		t.Errorf("decoded body got: %q want: %q", got, want)
+	}
+}
+
+func TestResponsePipelineNoBody(t *testing.T) {
+	m := NewServeMux(nil)
+	m.Handle("/", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.NoContent()
+	})
+	m.AddResponseStage(gzipper{})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusNoContent; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf(`rec.Header().Get("Content-Encoding") got: %q want: ""`, got)
+	}
+}
+
+func TestResponsePipelineError(t *testing.T) {
+	m := NewServeMux(nil)
+	m.Handle("/", "GET", TextFile("hello", time.Hour))
+	m.AddResponseStage(failingStage{})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Body.String(), "Internal Server Error\n"; got != want {
+		t.Errorf("rec.Body got: %q want: %q", got, want)
+	}
+}
+
+// varyStage adds Accept-Encoding to the Vary header of the response.
+type varyStage struct {
+	err *error
+}
+
+func (s varyStage) Transform(b ResponseBody) (ResponseBody, error) {
+	*s.err = b.AddVary("Accept-Encoding")
+	return b, nil
+}
+
+func TestResponsePipelineImmutableHeader(t *testing.T) {
+	var tests = []struct {
+		name      string
+		immutable bool
+		wantVary  string
+		wantErr   bool
+	}{
+		{
+			name:     "Mutable",
+			wantVary: "Origin, Accept-Encoding",
+		},
+		{
+			name:      "Immutable",
+			immutable: true,
+			wantVary:  "Origin",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			m := NewServeMux(nil)
+			m.Handle("/", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+				if err := w.Header().Set("Vary", "Origin"); err != nil {
+					t.Fatalf("Set() got err: %v", err)
+				}
+				if tt.immutable {
+					w.Header().MarkImmutable("Vary")
+				}
+				return w.writeBody("text/plain", []byte("hello"))
+			})
+			m.AddResponseStage(varyStage{err: &err})
+
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AddVary() got err: %v want error: %v", err, tt.wantErr)
+			}
+			if got := strings.Join(rec.Header().Values("Vary"), ", "); got != tt.wantVary {
+				t.Errorf(`rec.Header().Values("Vary") got: %q want: %q`, got, tt.wantVary)
+			}
+		})
+	}
+}
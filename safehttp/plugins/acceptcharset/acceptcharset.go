@@ -0,0 +1,41 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package acceptcharset provides an interceptor rejecting requests from
+// clients that don't accept UTF-8 responses.
+package acceptcharset
+
+import "github.com/google/go-safeweb/safehttp"
+
+// Interceptor rejects requests whose Accept-Charset headers exclude UTF-8,
+// either explicitly with "utf-8;q=0" or by only listing other charsets, with
+// 406 Not Acceptable. It is meant for endpoints that only emit UTF-8.
+// Requests without Accept-Charset header are accepted. Accept-Charset is
+// added to the Vary header of the responses.
+type Interceptor struct{}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if the client doesn't accept UTF-8.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	if !safehttp.AcceptsCharset(w, r, "utf-8") {
+		return w.ClientError(safehttp.Status406NotAcceptable)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acceptcharset
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestAcceptCharset(t *testing.T) {
+	var tests = []struct {
+		name     string
+		accept   []string
+		wantCode int
+	}{
+		{
+			name:     "Absent",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "UTF8",
+			accept:   []string{"utf-8, iso-8859-1;q=0.5"},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Wildcard",
+			accept:   []string{"iso-8859-1", "*;q=0.1"},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "OtherCharset",
+			accept:   []string{"iso-8859-1"},
+			wantCode: http.StatusNotAcceptable,
+		},
+		{
+			name:     "UTF8Excluded",
+			accept:   []string{"utf-8;q=0, *"},
+			wantCode: http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			})
+			m.Install(Interceptor{})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header["Accept-Charset"] = tt.accept
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
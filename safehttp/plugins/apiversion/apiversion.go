@@ -0,0 +1,65 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apiversion provides an interceptor requiring requests to versioned
+// APIs to select one of the supported versions.
+package apiversion
+
+import "github.com/google/go-safeweb/safehttp"
+
+// DefaultHeader is the header selecting the version when Header is empty.
+const DefaultHeader = "Api-Version"
+
+// Interceptor rejects requests whose version header doesn't match one of the
+// Supported versions with 400 Bad Request. Handlers can route on the version
+// by reading the header, which is always set after the interceptor ran.
+type Interceptor struct {
+	// Header is the name of the header selecting the version. Defaults to
+	// DefaultHeader.
+	Header string
+	// Supported are the supported versions.
+	Supported []string
+	// Default is the version used for requests without the header. If
+	// empty, the header is required.
+	Default string
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before validates the version requested, setting it to the Default if the
+// request didn't select one.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	header := it.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	version := r.Header.Get(header)
+	if version == "" && it.Default != "" {
+		version = it.Default
+		if err := r.Header.Set(header, version); err != nil {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+	}
+	for _, v := range it.Supported {
+		if v == version {
+			return safehttp.Result{}
+		}
+	}
+	return w.ClientError(safehttp.Status400BadRequest)
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestAPIVersion(t *testing.T) {
+	var tests = []struct {
+		name        string
+		it          Interceptor
+		version     string
+		wantCode    int
+		wantVersion string
+	}{
+		{
+			name:        "Supported",
+			it:          Interceptor{Supported: []string{"2020-01-01", "2020-06-01"}},
+			version:     "2020-06-01",
+			wantCode:    http.StatusNoContent,
+			wantVersion: "2020-06-01",
+		},
+		{
+			name:     "Unsupported",
+			it:       Interceptor{Supported: []string{"2020-01-01", "2020-06-01"}},
+			version:  "2019-01-01",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Missing",
+			it:       Interceptor{Supported: []string{"2020-01-01"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Default",
+			it:          Interceptor{Supported: []string{"2020-01-01"}, Default: "2020-01-01"},
+			wantCode:    http.StatusNoContent,
+			wantVersion: "2020-01-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotVersion string
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				gotVersion = r.Header.Get(DefaultHeader)
+				return w.NoContent()
+			})
+			m.Install(tt.it)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.version != "" {
+				req.Header.Set(DefaultHeader, tt.version)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+			if gotVersion != tt.wantVersion {
+				t.Errorf("handler version got: %q want: %q", gotVersion, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auditlog provides an interceptor recording every request and its
+// response in an audit log, with the secrets they carry redacted.
+package auditlog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Redacted replaces the values of the sensitive headers, cookies and query
+// parameters in the log.
+const Redacted = "REDACTED"
+
+// Phase is the phase of the request an Entry is recorded in.
+type Phase string
+
+const (
+	// Request entries are recorded before the request is handled.
+	Request Phase = "request"
+	// Response entries are recorded when the response is committed.
+	Response Phase = "response"
+)
+
+// Entry is a record of the audit log.
+type Entry struct {
+	Phase Phase
+	// Method, Path and Query are the ones of the request, with the values of
+	// the sensitive query parameters redacted.
+	Method string
+	Path   string
+	Query  string
+	// Host is the host the request was sent to.
+	Host string
+	// RemoteAddr is the network address of the client or of the last proxy.
+	RemoteAddr string
+	// Header contains the headers of the request, with the values of the
+	// sensitive headers and cookies redacted.
+	Header http.Header
+	// StatusCode is the status code the response is sent with. It is only
+	// set in Response entries.
+	StatusCode safehttp.StatusCode
+	// Duration is the time elapsed between the request and the response
+	// entries. It is only set in Response entries.
+	Duration time.Duration
+}
+
+// Sink receives the entries of the audit log, e.g. to forward them to a
+// logging backend. It must be safe for concurrent use.
+type Sink interface {
+	Log(e Entry)
+}
+
+// Config configures the interceptor returned by NewInterceptor.
+type Config struct {
+	// Sink receives the entries of the log. It is required.
+	Sink Sink
+	// Headers are the names of the headers whose values are redacted,
+	// matched case-insensitively, e.g. "Authorization".
+	Headers []string
+	// Cookies are the names of the cookies whose values are redacted,
+	// e.g. the session cookie.
+	Cookies []string
+	// QueryParams are the names of the query parameters whose values are
+	// redacted, e.g. "token".
+	QueryParams []string
+}
+
+// Interceptor records a Request entry in its Before phase and a Response
+// entry once the response is written, with the status code it was sent with,
+// even if another interceptor replaced it with an error. Requests rejected
+// by the Before phase of an interceptor installed earlier aren't recorded.
+type Interceptor struct {
+	sink    Sink
+	headers map[string]bool
+	cookies map[string]bool
+	params  map[string]bool
+	now     func() time.Time
+}
+
+var (
+	_ safehttp.Interceptor = Interceptor{}
+	_ safehttp.Finisher    = Interceptor{}
+)
+
+// NewInterceptor creates an interceptor logging to the sink of the given
+// config.
+func NewInterceptor(cfg Config) (Interceptor, error) {
+	if cfg.Sink == nil {
+		return Interceptor{}, errors.New("nil sink")
+	}
+	it := Interceptor{
+		sink:    cfg.Sink,
+		headers: map[string]bool{},
+		cookies: map[string]bool{},
+		params:  map[string]bool{},
+		now:     time.Now,
+	}
+	for _, h := range cfg.Headers {
+		it.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, c := range cfg.Cookies {
+		it.cookies[c] = true
+	}
+	for _, p := range cfg.QueryParams {
+		it.params[p] = true
+	}
+	return it, nil
+}
+
+type requestKey struct{}
+
+type request struct {
+	entry Entry
+	start time.Time
+}
+
+// Before records the Request entry.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	e := it.entry(r)
+	r.SetContext(context.WithValue(r.Context(), requestKey{}, request{entry: e, start: it.now()}))
+	it.sink.Log(e)
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Finish records the Response entry.
+func (it Interceptor) Finish(r *safehttp.IncomingRequest, code safehttp.StatusCode) {
+	req, ok := r.Context().Value(requestKey{}).(request)
+	if !ok {
+		return
+	}
+	e := req.entry
+	e.Phase = Response
+	e.StatusCode = code
+	e.Duration = it.now().Sub(req.start)
+	it.sink.Log(e)
+}
+
+func (it Interceptor) entry(r *safehttp.IncomingRequest) Entry {
+	u := r.URL()
+	e := Entry{
+		Phase:      Request,
+		Method:     r.Method(),
+		Path:       u.Path,
+		Query:      it.redactQuery(u.RawQuery),
+		Host:       r.Host(),
+		RemoteAddr: r.RemoteAddr(),
+		Header:     http.Header{},
+	}
+	for _, name := range r.Header.Names() {
+		values := append([]string(nil), r.Header.Values(name)...)
+		switch {
+		case it.headers[http.CanonicalHeaderKey(name)]:
+			for i := range values {
+				values[i] = Redacted
+			}
+		case http.CanonicalHeaderKey(name) == "Cookie":
+			for i, v := range values {
+				values[i] = it.redactCookies(v)
+			}
+		}
+		e.Header[name] = values
+	}
+	return e
+}
+
+// redactQuery redacts the values of the sensitive parameters in the query.
+// Malformed queries are redacted entirely if there are sensitive parameters.
+func (it Interceptor) redactQuery(query string) string {
+	if len(it.params) == 0 || query == "" {
+		return query
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return Redacted
+	}
+	for name, vs := range values {
+		if it.params[name] {
+			for i := range vs {
+				vs[i] = Redacted
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// redactCookies redacts the values of the sensitive cookies in the value of
+// a Cookie header.
+func (it Interceptor) redactCookies(header string) string {
+	if len(it.cookies) == 0 {
+		return header
+	}
+	pairs := strings.Split(header, ";")
+	for i, p := range pairs {
+		p = strings.TrimSpace(p)
+		if j := strings.IndexByte(p, '='); j >= 0 && it.cookies[p[:j]] {
+			p = p[:j+1] + Redacted
+		}
+		pairs[i] = p
+	}
+	return strings.Join(pairs, "; ")
+}
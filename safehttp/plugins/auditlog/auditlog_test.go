@@ -0,0 +1,159 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-safeweb/safehttp"
+)
+
+type recordingSink struct {
+	entries []Entry
+}
+
+func (s *recordingSink) Log(e Entry) {
+	s.entries = append(s.entries, e)
+}
+
+func TestAuditLog(t *testing.T) {
+	sink := &recordingSink{}
+	it, err := NewInterceptor(Config{
+		Sink:        sink,
+		Headers:     []string{"authorization", "X-API-KEY"},
+		Cookies:     []string{"session"},
+		QueryParams: []string{"token"},
+	})
+	if err != nil {
+		t.Fatalf("NewInterceptor() got err: %v", err)
+	}
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	it.now = func() time.Time { return now }
+
+	m := safehttp.NewMachinery(func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		now = now.Add(150 * time.Millisecond)
+		return w.ClientError(safehttp.Status403Forbidden)
+	}, nil)
+	m.Install(it)
+
+	req := httptest.NewRequest("GET", "https://example.com/admin?token=secret&page=2", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Api-Key", "secret")
+	req.Header.Set("Cookie", "session=secret; theme=dark")
+	req.Header.Set("Accept", "text/html")
+	m.HandleRequest(httptest.NewRecorder(), req)
+
+	wantRequest := Entry{
+		Phase:      Request,
+		Method:     "GET",
+		Path:       "/admin",
+		Query:      "page=2&token=REDACTED",
+		Host:       "example.com",
+		RemoteAddr: "192.0.2.1:1234",
+		Header: http.Header{
+			"Accept":        {"text/html"},
+			"Authorization": {"REDACTED"},
+			"Cookie":        {"session=REDACTED; theme=dark"},
+			"X-Api-Key":     {"REDACTED"},
+		},
+	}
+	wantResponse := wantRequest
+	wantResponse.Phase = Response
+	wantResponse.StatusCode = safehttp.Status403Forbidden
+	wantResponse.Duration = 150 * time.Millisecond
+	want := []Entry{wantRequest, wantResponse}
+	if diff := cmp.Diff(want, sink.entries); diff != "" {
+		t.Errorf("sink.entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAuditLogNoRedaction(t *testing.T) {
+	sink := &recordingSink{}
+	it, err := NewInterceptor(Config{Sink: sink})
+	if err != nil {
+		t.Fatalf("NewInterceptor() got err: %v", err)
+	}
+	m := safehttp.NewMachinery(func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	}, nil)
+	m.Install(it)
+
+	req := httptest.NewRequest("GET", "/?token=a;b", nil)
+	req.Header.Set("Cookie", "session=abc")
+	m.HandleRequest(httptest.NewRecorder(), req)
+
+	if len(sink.entries) != 2 {
+		t.Fatalf("sink.entries got: %v want: 2 entries", sink.entries)
+	}
+	got := sink.entries[1]
+	if got.Query != "token=a;b" {
+		t.Errorf("Query got: %q want: %q", got.Query, "token=a;b")
+	}
+	if got, want := got.Header.Get("Cookie"), "session=abc"; got != want {
+		t.Errorf(`Header.Get("Cookie") got: %q want: %q`, got, want)
+	}
+	if got, want := got.StatusCode, safehttp.Status204NoContent; got != want {
+		t.Errorf("StatusCode got: %v want: %v", got, want)
+	}
+}
+
+func TestNewInterceptorNilSink(t *testing.T) {
+	if _, err := NewInterceptor(Config{}); err == nil {
+		t.Error("NewInterceptor(Config{}) got: nil error want: error")
+	}
+}
+
+type rejectingInterceptor struct{}
+
+func (rejectingInterceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+func (rejectingInterceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return w.ServerError(safehttp.Status503ServiceUnavailable, nil)
+}
+
+func TestAuditLogRejectedInCommit(t *testing.T) {
+	sink := &recordingSink{}
+	it, err := NewInterceptor(Config{Sink: sink})
+	if err != nil {
+		t.Fatalf("NewInterceptor() got err: %v", err)
+	}
+	m := safehttp.NewMachinery(func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	}, nil)
+	m.Install(it)
+	// rejectingInterceptor commits first and ends the commit phase.
+	m.Install(rejectingInterceptor{})
+
+	rec := httptest.NewRecorder()
+	m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if len(sink.entries) != 2 {
+		t.Fatalf("sink.entries got: %v want: 2 entries", sink.entries)
+	}
+	if got, want := sink.entries[1].StatusCode, safehttp.Status503ServiceUnavailable; got != want {
+		t.Errorf("StatusCode got: %v want: %v", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package canonicalhost provides interceptors enforcing the canonical host of
+// the application, either by redirecting requests for other hosts, e.g. from
+// www.example.com to example.com, or by rejecting them.
+package canonicalhost
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor redirects requests whose Host is not the canonical one with 301
+// Moved Permanently, preserving the path and the query of the request.
+type Interceptor struct {
+	// Host is the canonical host, optionally with a port.
+	Host string
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before redirects the request if it isn't targeted to the canonical host.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	if strings.EqualFold(r.Host(), it.Host) {
+		return safehttp.Result{}
+	}
+	u := r.URL()
+	target := url.URL{
+		Scheme:   "http",
+		Host:     it.Host,
+		Path:     u.Path,
+		RawPath:  u.RawPath,
+		RawQuery: u.RawQuery,
+	}
+	if r.IsTLS() {
+		target.Scheme = "https"
+	}
+	return w.Redirect(r, target.String(), safehttp.Status301MovedPermanently)
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canonicalhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func TestCanonicalHost(t *testing.T) {
+	var tests = []struct {
+		name         string
+		target       string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "WWW",
+			target:       "https://www.example.com/x?y=z",
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "https://example.com/x?y=z",
+		},
+		{
+			name:         "PlainHTTP",
+			target:       "http://www.example.com/x",
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "http://example.com/x",
+		},
+		{
+			name:         "EscapedPath",
+			target:       "https://www.example.com/a%2Fb",
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "https://example.com/a%2Fb",
+		},
+		{
+			name:     "Canonical",
+			target:   "https://example.com/x",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "CanonicalDifferentCase",
+			target:   "https://EXAMPLE.com/x",
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				return rw.Write(safehtml.HTMLEscaped("hello"))
+			}, safehttptest.Dispatcher{})
+			m.Install(Interceptor{Host: "example.com"})
+
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, httptest.NewRequest("GET", tt.target, nil))
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf(`rec.Header().Get("Location") got: %q want: %q`, got, tt.wantLocation)
+			}
+		})
+	}
+}
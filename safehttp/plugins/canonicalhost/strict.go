@@ -0,0 +1,59 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canonicalhost
+
+import (
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Strict rejects requests whose Host is not the canonical one with 400 Bad
+// Request, instead of redirecting them. It is meant for single-tenant
+// deployments, where requests for any other host are unexpected, e.g. DNS
+// rebinding attempts.
+//
+// Hosts are compared case-insensitively. The default port of the scheme of
+// the request, 443 for HTTPS and 80 for HTTP, can be omitted, both from the
+// Host of the request and from the canonical host. Any other port must match.
+type Strict struct {
+	// Host is the canonical host, optionally with a port.
+	Host string
+}
+
+var _ safehttp.Interceptor = Strict{}
+
+// Before rejects the request if it isn't targeted to the canonical host.
+func (it Strict) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	secure := r.IsTLS()
+	if !strings.EqualFold(trimDefaultPort(r.Host(), secure), trimDefaultPort(it.Host, secure)) {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Strict) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// trimDefaultPort removes the default port of the scheme from host.
+func trimDefaultPort(host string, secure bool) string {
+	port := ":80"
+	if secure {
+		port = ":443"
+	}
+	return strings.TrimSuffix(host, port)
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canonicalhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+)
+
+func TestStrict(t *testing.T) {
+	var tests = []struct {
+		name     string
+		host     string
+		target   string
+		wantCode int
+	}{
+		{
+			name:     "Exact",
+			host:     "example.com",
+			target:   "https://example.com/",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "DifferentCase",
+			host:     "example.com",
+			target:   "https://EXAMPLE.com/",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "DefaultHTTPSPort",
+			host:     "example.com",
+			target:   "https://example.com:443/",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "DefaultHTTPPort",
+			host:     "example.com",
+			target:   "http://example.com:80/",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "ConfiguredDefaultPort",
+			host:     "example.com:443",
+			target:   "https://example.com/",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "ConfiguredPort",
+			host:     "example.com:8443",
+			target:   "https://example.com:8443/",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Subdomain",
+			host:     "example.com",
+			target:   "https://www.example.com/",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Suffix",
+			host:     "example.com",
+			target:   "https://example.com.evil.com/",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "OtherPort",
+			host:     "example.com",
+			target:   "https://example.com:8443/",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "MissingConfiguredPort",
+			host:     "example.com:8443",
+			target:   "https://example.com/",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "PortOfOtherScheme",
+			host:     "example.com",
+			target:   "https://example.com:80/",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "EmptyPort",
+			host:     "example.com",
+			target:   "https://example.com:/",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "TrailingDot",
+			host:     "example.com",
+			target:   "https://example.com./",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				return rw.NoContent()
+			}, safehttptest.Dispatcher{})
+			m.Install(Strict{Host: tt.host})
+
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, httptest.NewRequest("GET", tt.target, nil))
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package coalesce provides a handler sharing a single execution of the
+// wrapped handler between concurrent identical GET requests, e.g. to protect
+// expensive pages from bursts of requests.
+package coalesce
+
+import (
+	"bytes"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Handler serves GET requests with the wrapped handler, typically a
+// safehttp.ServeMux, once for all the identical requests in flight. Requests
+// are identical if they have the same host, URL and values of the Cookie,
+// Authorization and Vary headers, so that responses are never shared between
+// users. The first request runs the handler and its buffered response is
+// copied to every request waiting for it. Other requests are passed to the
+// wrapped handler unchanged.
+//
+// The handler must not respond differently to identical requests, e.g.
+// based on the remote address, and must not stream responses: the
+// http.Flusher and http.Hijacker interfaces aren't available to it. If it
+// panics, the waiting requests are answered with 500 Internal Server Error.
+type Handler struct {
+	h    http.Handler
+	vary []string
+
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// call is a handler execution shared by identical requests.
+type call struct {
+	done     chan struct{}
+	resp     *response
+	panicked bool
+}
+
+// NewHandler creates a Handler wrapping h. The vary headers are the request
+// headers, besides Cookie and Authorization, the response of h depends on,
+// e.g. Accept-Language.
+func NewHandler(h http.Handler, vary ...string) *Handler {
+	names := []string{"Authorization", "Cookie"}
+	for _, name := range vary {
+		names = append(names, textproto.CanonicalMIMEHeaderKey(name))
+	}
+	return &Handler{h: h, vary: names, calls: map[string]*call{}}
+}
+
+// ServeHTTP serves the request, sharing the response with the identical
+// requests in flight if it is a GET request.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.h.ServeHTTP(w, r)
+		return
+	}
+	key := h.key(r)
+	h.mu.Lock()
+	if c, ok := h.calls[key]; ok {
+		h.mu.Unlock()
+		select {
+		case <-c.done:
+		case <-r.Context().Done():
+			return
+		}
+		if c.panicked {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		c.resp.writeTo(w)
+		return
+	}
+	c := &call{done: make(chan struct{}), resp: newResponse()}
+	h.calls[key] = c
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.calls, key)
+		h.mu.Unlock()
+		close(c.done)
+	}()
+	c.panicked = true
+	h.h.ServeHTTP(c.resp, r)
+	c.panicked = false
+	c.resp.writeTo(w)
+}
+
+// key identifies the identical requests.
+func (h *Handler) key(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.Host)
+	b.WriteString(r.URL.RequestURI())
+	for _, name := range h.vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(r.Header[name], ", "))
+	}
+	return b.String()
+}
+
+// response buffers the response of the wrapped handler.
+type response struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newResponse() *response {
+	return &response{header: http.Header{}}
+}
+
+func (resp *response) Header() http.Header {
+	return resp.header
+}
+
+func (resp *response) WriteHeader(code int) {
+	if resp.code == 0 {
+		resp.code = code
+	}
+}
+
+func (resp *response) Write(b []byte) (int, error) {
+	resp.WriteHeader(http.StatusOK)
+	return resp.body.Write(b)
+}
+
+// writeTo writes a copy of the response to w. It doesn't modify the
+// response, so it can be called concurrently.
+func (resp *response) writeTo(w http.ResponseWriter) {
+	h := w.Header()
+	for name, values := range resp.header {
+		h[name] = append([]string(nil), values...)
+	}
+	code := resp.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+	w.WriteHeader(code)
+	w.Write(resp.body.Bytes())
+}
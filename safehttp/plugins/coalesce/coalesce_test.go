@@ -0,0 +1,174 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coalesce
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	var calls int32
+	started, release := make(chan struct{}), make(chan struct{})
+	h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		w.Header().Set("X-Call", fmt.Sprint(atomic.LoadInt32(&calls)))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("shared"))
+	}))
+
+	recs := make([]*httptest.ResponseRecorder, 3)
+	var wg sync.WaitGroup
+	serve := func(i int) {
+		defer wg.Done()
+		recs[i] = httptest.NewRecorder()
+		h.ServeHTTP(recs[i], httptest.NewRequest("GET", "/expensive?a=b", nil))
+	}
+	wg.Add(len(recs))
+	go serve(0)
+	<-started
+	for i := 1; i < len(recs); i++ {
+		go serve(i)
+	}
+	// Give the other requests time to wait for the first one.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+		t.Errorf("handler calls got: %v want: %v", got, want)
+	}
+	for i, rec := range recs {
+		if got, want := rec.Code, http.StatusAccepted; got != want {
+			t.Errorf("recs[%d].Code got: %v want: %v", i, got, want)
+		}
+		if got, want := rec.Header().Get("X-Call"), "1"; got != want {
+			t.Errorf(`recs[%d].Header().Get("X-Call") got: %q want: %q`, i, got, want)
+		}
+		if got, want := rec.Body.String(), "shared"; got != want {
+			t.Errorf("recs[%d].Body got: %q want: %q", i, got, want)
+		}
+	}
+	// Each request gets its own copy of the headers.
+	recs[0].Header().Set("X-Call", "changed")
+	if got, want := recs[1].Header().Get("X-Call"), "1"; got != want {
+		t.Errorf(`recs[1].Header().Get("X-Call") got: %q want: %q`, got, want)
+	}
+}
+
+func TestNotCoalesced(t *testing.T) {
+	var tests = []struct {
+		name   string
+		vary   []string
+		method string
+		header http.Header
+	}{
+		{
+			name:   "Post",
+			method: "POST",
+		},
+		{
+			name:   "Cookie",
+			method: "GET",
+			header: http.Header{"Cookie": {"session=other"}},
+		},
+		{
+			name:   "Authorization",
+			method: "GET",
+			header: http.Header{"Authorization": {"Bearer other"}},
+		},
+		{
+			name:   "Vary",
+			vary:   []string{"accept-language"},
+			method: "GET",
+			header: http.Header{"Accept-Language": {"fr"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			started, release := make(chan struct{}), make(chan struct{})
+			h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					close(started)
+					<-release
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}), tt.vary...)
+
+			done := make(chan struct{})
+			go func() {
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/", nil))
+				close(done)
+			}()
+			<-started
+			// The second request must run the handler while the first one is
+			// still in flight.
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			close(release)
+			<-done
+
+			if got, want := atomic.LoadInt32(&calls), int32(2); got != want {
+				t.Errorf("handler calls got: %v want: %v", got, want)
+			}
+			if got, want := rec.Code, http.StatusNoContent; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPanic(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		panic("failed")
+	}))
+
+	go func() {
+		defer func() { recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	<-started
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		close(done)
+	}()
+	// Give the second request time to wait for the first one.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	<-done
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+}
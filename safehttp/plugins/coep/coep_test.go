@@ -0,0 +1,85 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coep
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestNewInterceptor(t *testing.T) {
+	var tests = []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{
+			name:   "Valid",
+			policy: Policy{Mode: RequireCORP, ReportingGroup: "default"},
+		},
+		{
+			name:    "InvalidMode",
+			policy:  Policy{Mode: "require-cors"},
+			wantErr: true,
+		},
+		{
+			name:    "EmptyMode",
+			policy:  Policy{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewInterceptor(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewInterceptor(%+v) got err: %v want err: %v", tt.policy, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnforcedAndReportOnly(t *testing.T) {
+	enforced, err := NewInterceptor(Policy{Mode: RequireCORP})
+	if err != nil {
+		t.Fatalf("NewInterceptor() got err: %v want: nil", err)
+	}
+	reportOnly, err := NewInterceptor(Policy{Mode: Credentialless, ReportingGroup: "coi", ReportOnly: true})
+	if err != nil {
+		t.Fatalf("NewInterceptor() got err: %v want: nil", err)
+	}
+	m := safehttp.NewServeMux(nil)
+	m.Install(enforced)
+	m.Install(reportOnly)
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusNoContent; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Cross-Origin-Embedder-Policy"), "require-corp"; got != want {
+		t.Errorf(`rec.Header().Get("Cross-Origin-Embedder-Policy") got: %q want: %q`, got, want)
+	}
+	if got, want := rec.Header().Get("Cross-Origin-Embedder-Policy-Report-Only"), `credentialless; report-to="coi"`; got != want {
+		t.Errorf(`rec.Header().Get("Cross-Origin-Embedder-Policy-Report-Only") got: %q want: %q`, got, want)
+	}
+}
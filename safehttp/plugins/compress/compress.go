@@ -0,0 +1,176 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compress provides a response pipeline stage compressing response
+// bodies with gzip or deflate, and an interceptor excluding responses that
+// carry secrets from compression.
+//
+// Compressing a response that contains both a secret, e.g. an XSRF token,
+// and input reflected from the request allows an attacker observing the size
+// of responses to recover the secret (see http://breachattack.com). Such
+// responses must be marked as sensitive, either for a whole route with the
+// Sensitive config or by the handler with MarkSensitive.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// DefaultMinSize is the minimum size of the bodies compressed by a Stage
+// when its MinSize is 0. Compressing smaller bodies is rarely worth it.
+const DefaultMinSize = 1024
+
+// Stage compresses the bodies of responses with gzip or deflate, as
+// negotiated with the Accept-Encoding header of the request, and adds
+// Accept-Encoding to the Vary header of responses it could compress, unless
+// the Vary header is immutable, in which case they aren't compressed. It
+// should come after the stages transforming the body in the response
+// pipeline (see safehttp.ServeMux.AddResponseStage), which sets the
+// Content-Length of the compressed body.
+//
+// Only text, JSON, JavaScript and XML bodies are compressed. Other types,
+// e.g. images and archives, are usually compressed already. Bodies that
+// already have a Content-Encoding, that are smaller than the minimum size,
+// or whose Cache-Control header has the no-transform directive, e.g. because
+// they are sensitive, are left untouched.
+type Stage struct {
+	// MinSize is the minimum size of the bodies to compress. Defaults to
+	// DefaultMinSize.
+	MinSize int
+}
+
+var _ safehttp.ResponseStage = Stage{}
+
+// Transform compresses b if it is eligible.
+func (s Stage) Transform(b safehttp.ResponseBody) (safehttp.ResponseBody, error) {
+	if b.ContentEncoding != "" || !compressible(b.ContentType) || noTransform(b.CacheControl()) {
+		return b, nil
+	}
+	// Bodies too small to be compressed are still negotiated, so that Vary
+	// is the same for every response of an endpoint.
+	encoding, ok := b.NegotiateEncoding("gzip", "deflate")
+	minSize := s.MinSize
+	if minSize == 0 {
+		minSize = DefaultMinSize
+	}
+	if len(b.Data) < minSize || !ok || encoding == "identity" {
+		return b, nil
+	}
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	if encoding == "gzip" {
+		w = gzip.NewWriter(&buf)
+	} else {
+		w = zlib.NewWriter(&buf)
+	}
+	if _, err := w.Write(b.Data); err != nil {
+		return b, err
+	}
+	if err := w.Close(); err != nil {
+		return b, err
+	}
+	b.ContentEncoding = encoding
+	b.Data = buf.Bytes()
+	return b, nil
+}
+
+// compressible reports whether bodies of the given content type benefit from
+// compression.
+func compressible(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mt, "text/"):
+		return true
+	case mt == "application/json", mt == "application/javascript", mt == "application/xml":
+		return true
+	}
+	return strings.HasSuffix(mt, "+json") || strings.HasSuffix(mt, "+xml")
+}
+
+// noTransform reports whether the Cache-Control header value has the
+// no-transform directive.
+func noTransform(cacheControl string) bool {
+	for _, d := range strings.Split(cacheControl, ",") {
+		if strings.EqualFold(strings.TrimSpace(d), "no-transform") {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkSensitive adds the no-transform directive to the Cache-Control header
+// of the response, so that neither the Stage nor intermediaries compress it.
+// It must be called after setting any other Cache-Control directives, as
+// setting the header again overwrites it.
+func MarkSensitive(w safehttp.ResponseWriter) error {
+	h := w.Header()
+	cc := h.Get("Cache-Control")
+	if noTransform(cc) {
+		return nil
+	}
+	if cc != "" {
+		cc += ", "
+	}
+	return h.Set("Cache-Control", cc+"no-transform")
+}
+
+// Interceptor marks the responses of the routes registered with the
+// Sensitive config as sensitive, with MarkSensitive. It doesn't affect other
+// routes.
+type Interceptor struct {
+	sensitive bool
+}
+
+var _ safehttp.ConfigurableInterceptor = Interceptor{}
+
+// Sensitive is an InterceptorConfig marking all the responses of a route as
+// sensitive, e.g. pages embedding an XSRF token.
+type Sensitive struct{}
+
+// Before is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Commit marks the response as sensitive if the route is. If the
+// Cache-Control header was made immutable without the no-transform
+// directive, it responds with 500 Internal Server Error.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	if !it.sensitive {
+		return safehttp.Result{}
+	}
+	if err := MarkSensitive(w); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	return safehttp.Result{}
+}
+
+// Configure marks the route as sensitive when cfg is Sensitive.
+func (it Interceptor) Configure(cfg safehttp.InterceptorConfig) (safehttp.Interceptor, bool) {
+	if _, ok := cfg.(Sensitive); !ok {
+		return it, false
+	}
+	return Interceptor{sensitive: true}, true
+}
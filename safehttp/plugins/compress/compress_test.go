@@ -0,0 +1,256 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+// page is plain text, so that it is written unchanged as escaped HTML.
+var page = strings.Repeat("Hello, world!\n", 100)
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	var err error
+	switch encoding {
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		r, err = zlib.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	if err != nil {
+		t.Fatalf("decoding %s: %v", encoding, err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decoding %s: %v", encoding, err)
+	}
+	return string(b)
+}
+
+func TestStage(t *testing.T) {
+	var tests = []struct {
+		name         string
+		path         string
+		accept       string
+		contentType  string
+		body         string
+		header       map[string]string
+		wantEncoding string
+		wantVary     string
+	}{
+		{
+			name:         "Gzip",
+			accept:       "gzip, deflate",
+			contentType:  "text/html; charset=utf-8",
+			body:         page,
+			wantEncoding: "gzip",
+			wantVary:     "Accept-Encoding",
+		},
+		{
+			name:         "Deflate",
+			accept:       "gzip;q=0.5, deflate",
+			contentType:  "application/json",
+			body:         page,
+			wantEncoding: "deflate",
+			wantVary:     "Accept-Encoding",
+		},
+		{
+			name:         "JSONSuffix",
+			accept:       "gzip",
+			contentType:  "application/problem+json",
+			body:         page,
+			wantEncoding: "gzip",
+			wantVary:     "Accept-Encoding",
+		},
+		{
+			name:        "NotAccepted",
+			contentType: "text/html; charset=utf-8",
+			body:        page,
+			wantVary:    "Accept-Encoding",
+		},
+		{
+			name:        "BelowMinSize",
+			accept:      "gzip",
+			contentType: "text/html; charset=utf-8",
+			body:        page[:99],
+			wantVary:    "Accept-Encoding",
+		},
+		{
+			name:        "Image",
+			accept:      "gzip",
+			contentType: "image/png",
+			body:        page,
+		},
+		{
+			name:         "AlreadyEncoded",
+			accept:       "gzip",
+			contentType:  "text/plain",
+			body:         page,
+			header:       map[string]string{"Content-Encoding": "br"},
+			wantEncoding: "br",
+		},
+		{
+			name:         "ContentLengthAlreadySet",
+			accept:       "gzip",
+			contentType:  "text/plain",
+			body:         page,
+			header:       map[string]string{"Content-Length": strconv.Itoa(len(page))},
+			wantEncoding: "gzip",
+			wantVary:     "Accept-Encoding",
+		},
+		{
+			name:        "MarkedSensitive",
+			accept:      "gzip",
+			contentType: "text/html; charset=utf-8",
+			body:        page,
+			header:      map[string]string{"Cache-Control": "private, no-transform"},
+		},
+		{
+			name:        "SensitiveRoute",
+			path:        "/account",
+			accept:      "gzip",
+			contentType: "text/html; charset=utf-8",
+			body:        page,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				for name, value := range tt.header {
+					if err := w.Header().Set(name, value); err != nil {
+						t.Fatalf("Set(%q) got err: %v", name, err)
+					}
+				}
+				if err := w.Header().Set("Content-Type", tt.contentType); err != nil {
+					t.Fatalf("Set(Content-Type) got err: %v", err)
+				}
+				return w.Write(safehtml.HTMLEscaped(tt.body))
+			}
+			m := safehttp.NewServeMux(safehttptest.Dispatcher{})
+			m.Install(Interceptor{})
+			m.AddResponseStage(Stage{MinSize: 100})
+			m.Handle("/", "GET", h)
+			m.Handle("/account", "GET", h, Sensitive{})
+
+			path := tt.path
+			if path == "" {
+				path = "/"
+			}
+			req := httptest.NewRequest("GET", path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if got, want := rec.Code, http.StatusOK; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf(`rec.Header().Get("Content-Encoding") got: %q want: %q`, got, tt.wantEncoding)
+			}
+			if got := rec.Header().Get("Vary"); got != tt.wantVary {
+				t.Errorf(`rec.Header().Get("Vary") got: %q want: %q`, got, tt.wantVary)
+			}
+			if got, want := rec.Header().Get("Content-Length"), strconv.Itoa(rec.Body.Len()); got != want {
+				t.Errorf(`rec.Header().Get("Content-Length") got: %q want: %q`, got, want)
+			}
+			if got := decode(t, tt.wantEncoding, rec.Body.Bytes()); got != tt.body {
+				t.Errorf("decoded body got: %q want: %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestStageHead(t *testing.T) {
+	m := safehttp.NewServeMux(safehttptest.Dispatcher{})
+	m.Install(Interceptor{})
+	m.AddResponseStage(Stage{MinSize: 100})
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		if err := w.Header().Set("Content-Type", "text/html; charset=utf-8"); err != nil {
+			t.Fatalf("Set(Content-Type) got err: %v", err)
+		}
+		return w.Write(safehtml.HTMLEscaped(page))
+	})
+
+	header := func(method string) (http.Header, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+		return rec.Header(), rec
+	}
+	wantHeader, get := header("GET")
+	gotHeader, head := header("HEAD")
+
+	if got, want := head.Code, get.Code; got != want {
+		t.Errorf("HEAD rec.Code got: %v want: %v", got, want)
+	}
+	for _, name := range []string{"Content-Type", "Content-Encoding", "Content-Length", "Vary"} {
+		if got, want := gotHeader.Get(name), wantHeader.Get(name); got != want {
+			t.Errorf("HEAD %s got: %q want: %q", name, got, want)
+		}
+	}
+	if got, want := gotHeader.Get("Content-Encoding"), "gzip"; got != want {
+		t.Errorf(`HEAD rec.Header().Get("Content-Encoding") got: %q want: %q`, got, want)
+	}
+	if got := head.Body.String(); got != "" {
+		t.Errorf("HEAD rec.Body got: %q want: empty", got)
+	}
+}
+
+func TestMarkSensitive(t *testing.T) {
+	m := safehttp.NewServeMux(safehttptest.Dispatcher{})
+	m.AddResponseStage(Stage{MinSize: 1})
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		if err := w.Header().Set("Cache-Control", "private"); err != nil {
+			t.Fatalf("Set() got err: %v", err)
+		}
+		if err := MarkSensitive(w); err != nil {
+			t.Fatalf("MarkSensitive() got err: %v", err)
+		}
+		return w.Write(safehtml.HTMLEscaped(page))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if got, want := rec.Header().Get("Cache-Control"), "private, no-transform"; got != want {
+		t.Errorf(`rec.Header().Get("Cache-Control") got: %q want: %q`, got, want)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf(`rec.Header().Get("Content-Encoding") got: %q want: ""`, got)
+	}
+}
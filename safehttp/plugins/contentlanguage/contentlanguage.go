@@ -0,0 +1,59 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contentlanguage provides an interceptor validating the language of
+// submitted content.
+package contentlanguage
+
+import (
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor rejects requests with a Content-Language header listing a
+// language that is not Supported with 400 Bad Request. Requests without the
+// header are accepted. Language tags are compared case-insensitively.
+type Interceptor struct {
+	// Supported are the supported language tags, e.g. "en-US".
+	Supported []string
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before validates the Content-Language header of the request.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	for _, v := range r.Header.Values("Content-Language") {
+		for _, lang := range strings.Split(v, ",") {
+			if !it.supported(strings.TrimSpace(lang)) {
+				return w.ClientError(safehttp.Status400BadRequest)
+			}
+		}
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+func (it Interceptor) supported(lang string) bool {
+	for _, s := range it.Supported {
+		if strings.EqualFold(s, lang) {
+			return true
+		}
+	}
+	return false
+}
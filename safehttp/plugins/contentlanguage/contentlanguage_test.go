@@ -0,0 +1,82 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contentlanguage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestContentLanguage(t *testing.T) {
+	var tests = []struct {
+		name     string
+		language string
+		wantCode int
+	}{
+		{
+			name:     "Supported",
+			language: "en-US",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "SupportedCaseInsensitive",
+			language: "fr-fr",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "SupportedList",
+			language: "en-US, fr-FR",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Unsupported",
+			language: "de-DE",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "PartlyUnsupported",
+			language: "en-US, de-DE",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Missing",
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", "POST", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			})
+			m.Install(Interceptor{Supported: []string{"en-US", "fr-FR"}})
+
+			req := httptest.NewRequest("POST", "/", nil)
+			if tt.language != "" {
+				req.Header.Set("Content-Language", tt.language)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contentlength provides an interceptor detecting request bodies
+// whose length doesn't match their Content-Length header, e.g. truncated
+// uploads.
+package contentlength
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// ErrLengthMismatch is returned when reading a request body whose length
+// doesn't match its Content-Length header.
+var ErrLengthMismatch = errors.New("contentlength: body length doesn't match Content-Length")
+
+// Interceptor verifies that the body of requests with a Content-Length header
+// has the declared length. Reading a shorter or longer body from the handler
+// fails with ErrLengthMismatch when the mismatch is detected, so that the
+// handler can reject the request with 400 Bad Request. Requests with a
+// malformed Content-Length header are rejected with 400 Bad Request before
+// reaching the handler.
+type Interceptor struct{}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before wraps the body of the request to count the bytes read from it.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	v := r.Header.Get("Content-Length")
+	if v == "" {
+		return safehttp.Result{}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	r.SetBody(&countingBody{ReadCloser: r.Body(), remaining: n})
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 || (err == io.EOF && b.remaining > 0) {
+		return n, ErrLengthMismatch
+	}
+	// The body of requests received by net/http is already limited to their
+	// Content-Length, so a truncated one fails with io.ErrUnexpectedEOF.
+	if err == io.ErrUnexpectedEOF {
+		return n, ErrLengthMismatch
+	}
+	return n, err
+}
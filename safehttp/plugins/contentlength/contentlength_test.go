@@ -0,0 +1,132 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contentlength
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestContentLength(t *testing.T) {
+	var tests = []struct {
+		name          string
+		contentLength string
+		body          string
+		wantCode      int
+	}{
+		{
+			name:          "Match",
+			contentLength: "5",
+			body:          "hello",
+			wantCode:      http.StatusNoContent,
+		},
+		{
+			name:          "Truncated",
+			contentLength: "10",
+			body:          "hello",
+			wantCode:      http.StatusBadRequest,
+		},
+		{
+			name:          "TooLong",
+			contentLength: "2",
+			body:          "hello",
+			wantCode:      http.StatusBadRequest,
+		},
+		{
+			name:          "Malformed",
+			contentLength: "five",
+			body:          "hello",
+			wantCode:      http.StatusBadRequest,
+		},
+		{
+			name:     "NoContentLength",
+			body:     "hello",
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", "POST", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				if _, err := ioutil.ReadAll(r.Body()); err == ErrLengthMismatch {
+					return w.ClientError(safehttp.Status400BadRequest)
+				} else if err != nil {
+					t.Errorf("ioutil.ReadAll() got err: %v", err)
+				}
+				return w.NoContent()
+			})
+			m.Install(Interceptor{})
+
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.Header.Del("Content-Length")
+			if tt.contentLength != "" {
+				req.Header.Set("Content-Length", tt.contentLength)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestContentLengthServer(t *testing.T) {
+	m := safehttp.NewServeMux(nil)
+	m.Handle("/", "POST", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		if _, err := ioutil.ReadAll(r.Body()); err == ErrLengthMismatch {
+			return w.ClientError(safehttp.Status400BadRequest)
+		} else if err != nil {
+			t.Errorf("ioutil.ReadAll() got err: %v", err)
+		}
+		return w.NoContent()
+	})
+	m.Install(Interceptor{})
+	s := httptest.NewServer(m)
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() got err: %v", err)
+	}
+	defer conn.Close()
+	// The body is shorter than its Content-Length: the client stops sending
+	// after "hello".
+	req := "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 10\r\n\r\nhello"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatalf("conn.Write() got err: %v", err)
+	}
+	if err := conn.(*net.TCPConn).CloseWrite(); err != nil {
+		t.Fatalf("conn.CloseWrite() got err: %v", err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("http.ReadResponse() got err: %v", err)
+	}
+	defer resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+		t.Errorf("resp.StatusCode got: %v want: %v", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contentrange provides an interceptor validating the Content-Range
+// header of partial uploads, e.g. the chunks of resumable uploads sent with
+// PUT, before they reach the handler.
+package contentrange
+
+import "github.com/google/go-safeweb/safehttp"
+
+// Interceptor rejects requests with a malformed Content-Range header with
+// 400 Bad Request, as well as requests whose Content-Length doesn't match the
+// length of the range. Handlers can then read the range with
+// IncomingRequest.ContentRange. Requests without the header are not checked.
+type Interceptor struct{}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if its Content-Range header is invalid.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	cr, ok, err := r.ContentRange()
+	if !ok {
+		return safehttp.Result{}
+	}
+	if err != nil {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	if n, ok := r.ContentLength(); ok && n != cr.Length() {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
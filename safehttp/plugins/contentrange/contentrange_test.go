@@ -0,0 +1,92 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contentrange
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestContentRange(t *testing.T) {
+	var tests = []struct {
+		name         string
+		contentRange string
+		body         string
+		wantCode     int
+		wantRange    safehttp.ContentRange
+	}{
+		{
+			name:         "Valid",
+			contentRange: "bytes 0-4/10",
+			body:         "hello",
+			wantCode:     http.StatusNoContent,
+			wantRange:    safehttp.ContentRange{First: 0, Last: 4, Size: 10},
+		},
+		{
+			name:         "UnknownSize",
+			contentRange: "bytes 5-9/*",
+			body:         "world",
+			wantCode:     http.StatusNoContent,
+			wantRange:    safehttp.ContentRange{First: 5, Last: 9, Size: -1},
+		},
+		{
+			name:         "Malformed",
+			contentRange: "bytes 5-/10",
+			body:         "world",
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "LengthMismatch",
+			contentRange: "bytes 0-9/10",
+			body:         "hello",
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:     "NoContentRange",
+			body:     "hello",
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got safehttp.ContentRange
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/upload", "PUT", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				got, _, _ = r.ContentRange()
+				return w.NoContent()
+			})
+			m.Install(Interceptor{})
+
+			req := httptest.NewRequest("PUT", "/upload", strings.NewReader(tt.body))
+			if tt.contentRange != "" {
+				req.Header.Set("Content-Range", tt.contentRange)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+			if got != tt.wantRange {
+				t.Errorf("r.ContentRange() got: %+v want: %+v", got, tt.wantRange)
+			}
+		})
+	}
+}
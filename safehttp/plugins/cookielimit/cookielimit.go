@@ -0,0 +1,75 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cookielimit provides an interceptor rejecting requests with
+// abnormally large Cookie headers or abnormally many cookies, which usually
+// result from cookies accumulating over time.
+package cookielimit
+
+import (
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor rejects requests whose Cookie headers are larger than MaxSize
+// bytes or contain more than MaxCount cookies with 400 Bad Request.
+type Interceptor struct {
+	// MaxSize is the maximum total size of the Cookie headers, in bytes. If
+	// zero, the size of the headers is not limited.
+	MaxSize int
+	// MaxCount is the maximum number of cookies. If zero, the number of
+	// cookies is not limited.
+	MaxCount int
+	// ClearCookies makes rejected responses instruct the client to delete
+	// its cookies, so that subsequent requests succeed. It sets the
+	// Clear-Site-Data header and expires every cookie sent in the request
+	// with Header.DeleteCookie, which satisfies the cookie policy.
+	ClearCookies bool
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if its Cookie headers are too large or contain
+// too many cookies.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	size, count := 0, 0
+	for _, v := range r.Header.Values("Cookie") {
+		size += len(v)
+		for _, c := range strings.Split(v, ";") {
+			if strings.TrimSpace(c) != "" {
+				count++
+			}
+		}
+	}
+	if (it.MaxSize == 0 || size <= it.MaxSize) && (it.MaxCount == 0 || count <= it.MaxCount) {
+		return safehttp.Result{}
+	}
+	if it.ClearCookies {
+		if err := w.Header().Set("Clear-Site-Data", `"cookies"`); err != nil {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+		for _, c := range r.Cookies() {
+			if err := w.Header().DeleteCookie(c.Name); err != nil {
+				return w.ServerError(safehttp.Status500InternalServerError, nil)
+			}
+		}
+	}
+	return w.ClientError(safehttp.Status400BadRequest)
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cookielimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func TestCookieSize(t *testing.T) {
+	var tests = []struct {
+		name           string
+		cookie         string
+		clear          bool
+		wantCode       int
+		wantClear      string
+		wantSetCookies []string
+	}{
+		{
+			name:     "Small",
+			cookie:   "a=b",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "AtLimit",
+			cookie:   "a=" + strings.Repeat("x", 18),
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Oversized",
+			cookie:   "a=" + strings.Repeat("x", 19),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:           "OversizedClear",
+			cookie:         "a=" + strings.Repeat("x", 10) + "; b=" + strings.Repeat("y", 10),
+			clear:          true,
+			wantCode:       http.StatusBadRequest,
+			wantClear:      `"cookies"`,
+			wantSetCookies: []string{"a=; Path=/; Max-Age=0", "b=; Path=/; Max-Age=0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				return rw.Write(safehtml.HTMLEscaped("hello"))
+			}, safehttptest.Dispatcher{})
+			m.Install(Interceptor{MaxSize: 20, ClearCookies: tt.clear})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Cookie", tt.cookie)
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, req)
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Header().Get("Clear-Site-Data"); got != tt.wantClear {
+				t.Errorf(`rec.Header().Get("Clear-Site-Data") got: %q want: %q`, got, tt.wantClear)
+			}
+			if diff := cmp.Diff(tt.wantSetCookies, rec.Header()["Set-Cookie"]); diff != "" {
+				t.Errorf(`rec.Header()["Set-Cookie"] mismatch (-want +got):\n%s`, diff)
+			}
+		})
+	}
+}
+
+func TestCookieCount(t *testing.T) {
+	var tests = []struct {
+		name     string
+		cookies  []string
+		maxCount int
+		wantCode int
+	}{
+		{
+			name:     "BelowLimit",
+			cookies:  []string{"a=1; b=2"},
+			maxCount: 3,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "AtLimit",
+			cookies:  []string{"a=1; b=2; c=3"},
+			maxCount: 3,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "AboveLimit",
+			cookies:  []string{"a=1; b=2; c=3; d=4"},
+			maxCount: 3,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "AboveLimitAcrossHeaders",
+			cookies:  []string{"a=1; b=2", "c=3; d=4"},
+			maxCount: 3,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "NoLimit",
+			cookies:  []string{"a=1; b=2; c=3; d=4"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "NoSizeLimit",
+			cookies:  []string{"a=" + strings.Repeat("x", 4096)},
+			maxCount: 3,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				return rw.Write(safehtml.HTMLEscaped("hello"))
+			}, safehttptest.Dispatcher{})
+			m.Install(Interceptor{MaxCount: tt.maxCount})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header["Cookie"] = tt.cookies
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, req)
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestClearCookiesCookiePolicy(t *testing.T) {
+	m := safehttp.NewServeMux(safehttptest.Dispatcher{})
+	m.SetCookiePolicy(safehttp.CookiePolicy{RequireSameSite: true, RequireSecure: true})
+	m.Install(Interceptor{MaxSize: 20, ClearCookies: true})
+	m.Handle("/", "GET", func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+		return rw.Write(safehtml.HTMLEscaped("hello"))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Cookie", "a="+strings.Repeat("x", 10)+"; b="+strings.Repeat("y", 10))
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if got, want := rec.Code, http.StatusBadRequest; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	want := []string{
+		"a=; Path=/; Max-Age=0; Secure; SameSite=Lax",
+		"b=; Path=/; Max-Age=0; Secure; SameSite=Lax",
+	}
+	if diff := cmp.Diff(want, rec.Header()["Set-Cookie"]); diff != "" {
+		t.Errorf(`rec.Header()["Set-Cookie"] mismatch (-want +got):\n%s`, diff)
+	}
+}
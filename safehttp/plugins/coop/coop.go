@@ -0,0 +1,77 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package coop provides an interceptor setting the Cross-Origin-Opener-Policy
+// header, which isolates the browsing context of the page from cross-origin
+// documents it opens or is opened by. Together with the
+// Cross-Origin-Embedder-Policy header (see package coep), it enables
+// cross-origin isolation.
+//
+// See https://html.spec.whatwg.org/multipage/origin.html#cross-origin-opener-policies
+// for more details.
+package coop
+
+import (
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/plugins/internal/crossorigin"
+)
+
+// Mode is the mode of a Cross-Origin-Opener-Policy.
+type Mode string
+
+// The modes of a Cross-Origin-Opener-Policy.
+const (
+	UnsafeNone            Mode = "unsafe-none"
+	SameOriginAllowPopups Mode = "same-origin-allow-popups"
+	SameOrigin            Mode = "same-origin"
+)
+
+var modes = []string{string(UnsafeNone), string(SameOriginAllowPopups), string(SameOrigin)}
+
+// Policy is a Cross-Origin-Opener-Policy.
+type Policy struct {
+	// Mode is the mode of the policy.
+	Mode Mode
+	// ReportingGroup is the name of the reporting endpoint violations are
+	// reported to, as defined by the Reporting-Endpoints or Report-To
+	// header. If empty, violations are not reported.
+	ReportingGroup string
+	// ReportOnly makes the policy report violations without enforcing it,
+	// using the Cross-Origin-Opener-Policy-Report-Only header.
+	ReportOnly bool
+}
+
+// Interceptor sets the Cross-Origin-Opener-Policy or
+// Cross-Origin-Opener-Policy-Report-Only header on every response. An
+// enforced and a report-only policy can be used together by installing an
+// interceptor for each.
+type Interceptor struct {
+	crossorigin.Interceptor
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// NewInterceptor validates the given policy and creates an interceptor
+// setting it.
+func NewInterceptor(p Policy) (Interceptor, error) {
+	it, err := crossorigin.NewInterceptor("Cross-Origin-Opener-Policy", modes, crossorigin.Policy{
+		Mode:           string(p.Mode),
+		ReportingGroup: p.ReportingGroup,
+		ReportOnly:     p.ReportOnly,
+	})
+	if err != nil {
+		return Interceptor{}, err
+	}
+	return Interceptor{it}, nil
+}
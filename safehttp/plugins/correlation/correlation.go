@@ -0,0 +1,127 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package correlation provides an interceptor propagating the correlation ID
+// identifying a request across the services of a mesh.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+const (
+	// DefaultHeader is the header carrying the correlation ID when Header is
+	// empty.
+	DefaultHeader = "X-Correlation-Id"
+	// MaxLength is the maximum length of the correlation IDs accepted from
+	// upstream.
+	MaxLength = 128
+)
+
+// Interceptor reads the correlation ID of requests from the configured header
+// and makes it available to handlers through ID. The ID is also set on the
+// response.
+//
+// The header is only accepted on requests from trusted sources and if its
+// value is at most MaxLength ASCII letters, digits, '-', '_', '.' or ':'. In
+// strict mode, other requests are rejected with 400 Bad Request. Otherwise,
+// a random ID is generated for them.
+type Interceptor struct {
+	// Header is the header carrying the correlation ID. Defaults to
+	// DefaultHeader.
+	Header string
+	// Strict makes requests without a valid correlation ID from a trusted
+	// source fail instead of getting a generated one.
+	Strict bool
+	// Trusted reports whether the request comes from a trusted source, e.g.
+	// based on its client certificate. If nil, all requests are trusted,
+	// which is only appropriate if the server can't be reached from outside
+	// the mesh.
+	Trusted func(r *safehttp.IncomingRequest) bool
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+type idKey struct{}
+
+// ID returns the correlation ID of the request the context belongs to.
+func ID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(idKey{}).(string)
+	return id, ok
+}
+
+// Before reads or generates the correlation ID of the request and stores it
+// in the context of the request.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	id := r.Header.Get(it.header())
+	if !validID(id) || (it.Trusted != nil && !it.Trusted(r)) {
+		if it.Strict {
+			return w.ClientError(safehttp.Status400BadRequest)
+		}
+		var err error
+		if id, err = newID(); err != nil {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+	}
+	r.SetContext(context.WithValue(r.Context(), idKey{}, id))
+	return safehttp.Result{}
+}
+
+// Commit sets the correlation ID on the response.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	id, ok := ID(r.Context())
+	if !ok {
+		return safehttp.Result{}
+	}
+	if err := w.Header().Set(it.header(), id); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	return safehttp.Result{}
+}
+
+func (it Interceptor) header() string {
+	if it.Header == "" {
+		return DefaultHeader
+	}
+	return it.Header
+}
+
+func validID(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newID generates a random 128-bit correlation ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package correlation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+var generated = regexp.MustCompile("^[0-9a-f]{32}$")
+
+func TestCorrelation(t *testing.T) {
+	trustedOnly := func(r *safehttp.IncomingRequest) bool {
+		return r.RemoteAddr() == "10.0.0.1:1234"
+	}
+	var tests = []struct {
+		name          string
+		it            Interceptor
+		header        string
+		value         string
+		remoteAddr    string
+		wantCode      int
+		wantID        string
+		wantGenerated bool
+	}{
+		{
+			name:     "StrictValid",
+			it:       Interceptor{Strict: true},
+			header:   "X-Correlation-Id",
+			value:    "req-123:abc.DEF_4",
+			wantCode: http.StatusNoContent,
+			wantID:   "req-123:abc.DEF_4",
+		},
+		{
+			name:     "StrictMissing",
+			it:       Interceptor{Strict: true},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "StrictInvalid",
+			it:       Interceptor{Strict: true},
+			header:   "X-Correlation-Id",
+			value:    "<script>",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "StrictTooLong",
+			it:       Interceptor{Strict: true},
+			header:   "X-Correlation-Id",
+			value:    strings.Repeat("a", MaxLength+1),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:       "StrictTrustedSource",
+			it:         Interceptor{Strict: true, Trusted: trustedOnly},
+			header:     "X-Correlation-Id",
+			value:      "abc",
+			remoteAddr: "10.0.0.1:1234",
+			wantCode:   http.StatusNoContent,
+			wantID:     "abc",
+		},
+		{
+			name:       "StrictUntrustedSource",
+			it:         Interceptor{Strict: true, Trusted: trustedOnly},
+			header:     "X-Correlation-Id",
+			value:      "abc",
+			remoteAddr: "192.0.2.1:1234",
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:     "LenientValid",
+			header:   "X-Correlation-Id",
+			value:    "abc",
+			wantCode: http.StatusNoContent,
+			wantID:   "abc",
+		},
+		{
+			name:          "LenientMissing",
+			wantCode:      http.StatusNoContent,
+			wantGenerated: true,
+		},
+		{
+			name:          "LenientInvalid",
+			header:        "X-Correlation-Id",
+			value:         "a b",
+			wantCode:      http.StatusNoContent,
+			wantGenerated: true,
+		},
+		{
+			name:          "LenientUntrustedSource",
+			it:            Interceptor{Trusted: trustedOnly},
+			header:        "X-Correlation-Id",
+			value:         "abc",
+			remoteAddr:    "192.0.2.1:1234",
+			wantCode:      http.StatusNoContent,
+			wantGenerated: true,
+		},
+		{
+			name:     "CustomHeader",
+			it:       Interceptor{Header: "X-Request-Id", Strict: true},
+			header:   "X-Request-Id",
+			value:    "abc",
+			wantCode: http.StatusNoContent,
+			wantID:   "abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotID string
+			m := safehttp.NewMachinery(func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				id, ok := ID(r.Context())
+				if !ok {
+					t.Error("ID() got: none want: an ID")
+				}
+				gotID = id
+				return w.NoContent()
+			}, nil)
+			m.Install(tt.it)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusNoContent {
+				return
+			}
+			if tt.wantGenerated {
+				if !generated.MatchString(gotID) {
+					t.Errorf("ID() got: %q want: a generated ID", gotID)
+				}
+			} else if gotID != tt.wantID {
+				t.Errorf("ID() got: %q want: %q", gotID, tt.wantID)
+			}
+			header := tt.it.Header
+			if header == "" {
+				header = DefaultHeader
+			}
+			if got := rec.Header().Get(header); got != gotID {
+				t.Errorf("rec.Header().Get(%q) got: %q want: %q", header, got, gotID)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csrfcookie provides an interceptor implementing the double-submit
+// cookie pattern expected by SPA frameworks such as Angular, which read the
+// CSRF token from a cookie and echo it in a request header.
+package csrfcookie
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+const (
+	// DefaultCookieName is the name of the cookie used when CookieName is
+	// empty.
+	DefaultCookieName = "XSRF-TOKEN"
+	// DefaultHeaderName is the name of the header used when HeaderName is
+	// empty.
+	DefaultHeaderName = "X-XSRF-TOKEN"
+)
+
+// ErrNoToken is returned by Token when the request wasn't processed by the
+// Interceptor.
+var ErrNoToken = errors.New("csrfcookie: no token, the interceptor is not installed")
+
+// Interceptor sets a CSRF token in a cookie readable by scripts on safe
+// requests (GET, HEAD, OPTIONS and TRACE) that don't carry one yet. Other
+// requests are rejected with 403 Forbidden unless the header contains the
+// same token as the cookie. The token of the request is available to the
+// handler through Token.
+type Interceptor struct {
+	// CookieName is the name of the cookie holding the token. Defaults to
+	// DefaultCookieName.
+	CookieName string
+	// HeaderName is the name of the header the client echoes the token in.
+	// Defaults to DefaultHeaderName.
+	HeaderName string
+	// InsecureCookie sets the token cookie without the Secure attribute, so
+	// that browsers store it over plain HTTP, e.g. during local development
+	// with a CookiePolicy that doesn't RequireSecure. It must not be set in
+	// production, where the token would be sent over plain HTTP too.
+	InsecureCookie bool
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before sets the token cookie on safe requests and validates the token on
+// the other ones.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	cookieName, headerName := it.CookieName, it.HeaderName
+	if cookieName == "" {
+		cookieName = DefaultCookieName
+	}
+	if headerName == "" {
+		headerName = DefaultHeaderName
+	}
+	cookie, err := r.Cookie(cookieName)
+
+	switch r.Method() {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		if err == nil && cookie.Value != "" {
+			setToken(r, cookie.Value)
+			return safehttp.Result{}
+		}
+		token, err := newToken()
+		if err != nil {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+		err = w.Header().SetCookie(&http.Cookie{
+			Name:     cookieName,
+			Value:    token,
+			Path:     "/",
+			Secure:   !it.InsecureCookie,
+			SameSite: http.SameSiteStrictMode,
+		})
+		if err != nil {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+		setToken(r, token)
+		return safehttp.Result{}
+	}
+
+	if err != nil || cookie.Value == "" {
+		return w.ClientError(safehttp.Status403Forbidden)
+	}
+	header := r.Header.Get(headerName)
+	if subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+		return w.ClientError(safehttp.Status403Forbidden)
+	}
+	setToken(r, cookie.Value)
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+type tokenKey struct{}
+
+func setToken(r *safehttp.IncomingRequest, token string) {
+	r.SetContext(context.WithValue(r.Context(), tokenKey{}, token))
+}
+
+// Token returns the CSRF token of the request, which the client must echo in
+// the header of its following unsafe requests, e.g. to render it in a page.
+// On requests without a token cookie, it is the token set in the response
+// cookie. It returns ErrNoToken if the request wasn't processed by the
+// Interceptor, instead of an empty token that would fail validation.
+func Token(r *safehttp.IncomingRequest) (string, error) {
+	token, ok := r.Context().Value(tokenKey{}).(string)
+	if !ok {
+		return "", ErrNoToken
+	}
+	return token, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
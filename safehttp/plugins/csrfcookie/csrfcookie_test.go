@@ -0,0 +1,212 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csrfcookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func newMux() *safehttp.ServeMux {
+	m := safehttp.NewServeMux(nil)
+	h := func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	}
+	m.Handle("/", "GET", h)
+	m.Handle("/", "POST", h)
+	m.Install(Interceptor{})
+	return m
+}
+
+func TestSetsCookie(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newMux().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusNoContent; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("len(cookies) got: %v want: 1", len(cookies))
+	}
+	c := cookies[0]
+	if c.Name != DefaultCookieName || c.Value == "" {
+		t.Errorf("cookie got: %s=%q want: %s=<token>", c.Name, c.Value, DefaultCookieName)
+	}
+	if c.HttpOnly {
+		t.Error("cookie.HttpOnly got: true want: false")
+	}
+	if !c.Secure {
+		t.Error("cookie.Secure got: false want: true")
+	}
+}
+
+func TestInsecureCookie(t *testing.T) {
+	m := safehttp.NewServeMux(nil)
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	})
+	m.Install(Interceptor{InsecureCookie: true})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "http://localhost/", nil))
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("len(cookies) got: %v want: 1", len(cookies))
+	}
+	if cookies[0].Secure {
+		t.Error("cookie.Secure got: true want: false")
+	}
+}
+
+func TestKeepsExistingCookie(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: DefaultCookieName, Value: "token"})
+	rec := httptest.NewRecorder()
+	newMux().ServeHTTP(rec, req)
+
+	if got := rec.Header().Values("Set-Cookie"); len(got) != 0 {
+		t.Errorf(`rec.Header().Values("Set-Cookie") got: %v want: none`, got)
+	}
+}
+
+func TestValidatesToken(t *testing.T) {
+	var tests = []struct {
+		name     string
+		cookie   string
+		header   string
+		wantCode int
+	}{
+		{
+			name:     "Match",
+			cookie:   "token",
+			header:   "token",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Mismatch",
+			cookie:   "token",
+			header:   "other",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "NoHeader",
+			cookie:   "token",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "NoCookie",
+			header:   "token",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: DefaultCookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(DefaultHeaderName, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			newMux().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	var tests = []struct {
+		name   string
+		method string
+		cookie string
+		header string
+		want   string
+	}{
+		{name: "ExistingCookie", method: "GET", cookie: "abc", want: "abc"},
+		{name: "ValidatedToken", method: "POST", cookie: "abc", header: "abc", want: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			var gotErr error
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", tt.method, func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				got, gotErr = Token(r)
+				return w.NoContent()
+			})
+			m.Install(Interceptor{})
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.AddCookie(&http.Cookie{Name: DefaultCookieName, Value: tt.cookie})
+			if tt.header != "" {
+				req.Header.Set(DefaultHeaderName, tt.header)
+			}
+			m.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotErr != nil || got != tt.want {
+				t.Errorf("Token(r) got: %q, %v want: %q, nil", got, gotErr, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenNewCookie(t *testing.T) {
+	var got string
+	m := safehttp.NewServeMux(nil)
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		var err error
+		if got, err = Token(r); err != nil {
+			t.Errorf("Token(r) got err: %v", err)
+		}
+		return w.NoContent()
+	})
+	m.Install(Interceptor{})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("cookies got: %v want: 1 cookie", cookies)
+	}
+	if got == "" || got != cookies[0].Value {
+		t.Errorf("Token(r) got: %q want: %q", got, cookies[0].Value)
+	}
+}
+
+func TestTokenNotInstalled(t *testing.T) {
+	var gotErr error
+	m := safehttp.NewServeMux(nil)
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		_, gotErr = Token(r)
+		return w.NoContent()
+	})
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if gotErr != ErrNoToken {
+		t.Errorf("Token(r) got err: %v want: %v", gotErr, ErrNoToken)
+	}
+}
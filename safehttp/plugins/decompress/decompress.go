@@ -0,0 +1,94 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package decompress provides an interceptor that transparently decodes
+// request bodies sent with a Content-Encoding, so that handlers always see
+// the uncompressed body.
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// DefaultMaxSize is the maximum size of the decoded bodies if the MaxSize of
+// the Interceptor is 0.
+const DefaultMaxSize = 10 << 20
+
+// Interceptor decodes request bodies compressed with gzip or deflate. The
+// decoded body is limited to MaxSize bytes, to protect against decompression
+// bombs.
+//
+// Requests are rejected with:
+//   - 415 Unsupported Media Type if the content coding is not supported,
+//   - 400 Bad Request if the body can't be decoded,
+//   - 413 Payload Too Large if the decoded body exceeds MaxSize.
+type Interceptor struct {
+	// MaxSize is the maximum size of the decoded body, in bytes. If 0,
+	// DefaultMaxSize is used.
+	MaxSize int64
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before decodes the body of the request, if it is compressed. The decoded
+// body replaces the original one and the Content-Encoding and Content-Length
+// headers are removed.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	var dec io.ReadCloser
+	var err error
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return safehttp.Result{}
+	case "gzip", "x-gzip":
+		dec, err = gzip.NewReader(r.Body())
+	case "deflate":
+		dec, err = zlib.NewReader(r.Body())
+	default:
+		return w.ClientError(safehttp.Status415UnsupportedMediaType)
+	}
+	if err != nil {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	defer dec.Close()
+
+	max := it.MaxSize
+	if max == 0 {
+		max = DefaultMaxSize
+	}
+	// Read one byte more than allowed to detect oversized bodies.
+	body, err := ioutil.ReadAll(io.LimitReader(dec, max+1))
+	if err != nil {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	if int64(len(body)) > max {
+		return w.ClientError(safehttp.Status413PayloadTooLarge)
+	}
+	r.Body().Close()
+	r.SetBody(ioutil.NopCloser(bytes.NewReader(body)))
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
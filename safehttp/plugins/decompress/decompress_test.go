@@ -0,0 +1,153 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func gzipped(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		t.Fatalf("zw.Write() got err: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zw.Close() got err: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	var tests = []struct {
+		name     string
+		encoding string
+		body     []byte
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Gzip",
+			encoding: "gzip",
+			body:     gzipped(t, []byte("hello")),
+			wantCode: http.StatusOK,
+			wantBody: "hello",
+		},
+		{
+			name:     "Identity",
+			body:     []byte("hello"),
+			wantCode: http.StatusOK,
+			wantBody: "hello",
+		},
+		{
+			name:     "Bomb",
+			encoding: "gzip",
+			body:     gzipped(t, make([]byte, 1<<20)),
+			wantCode: http.StatusRequestEntityTooLarge,
+			wantBody: "Request Entity Too Large\n",
+		},
+		{
+			name:     "Malformed",
+			encoding: "gzip",
+			body:     []byte("not gzip"),
+			wantCode: http.StatusBadRequest,
+			wantBody: "Bad Request\n",
+		},
+		{
+			name:     "Unsupported",
+			encoding: "br",
+			body:     []byte("whatever"),
+			wantCode: http.StatusUnsupportedMediaType,
+			wantBody: "Unsupported Media Type\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				b, err := ioutil.ReadAll(r.Body())
+				if err != nil {
+					t.Fatalf("ioutil.ReadAll(r.Body()) got err: %v", err)
+				}
+				if got := r.Header.Get("Content-Encoding"); got != "" {
+					t.Errorf(`r.Header.Get("Content-Encoding") got: %q want: ""`, got)
+				}
+				return rw.Write(safehtml.HTMLEscaped(string(b)))
+			}, safehttptest.Dispatcher{})
+			m.Install(Interceptor{MaxSize: 1024})
+
+			req := httptest.NewRequest("POST", "/", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, req)
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestDefaultMaxSize(t *testing.T) {
+	var tests = []struct {
+		name     string
+		size     int
+		wantCode int
+	}{
+		{
+			name:     "AtLimit",
+			size:     DefaultMaxSize,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Oversized",
+			size:     DefaultMaxSize + 1,
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return rw.NoContent()
+			}, nil)
+			m.Install(Interceptor{})
+
+			req := httptest.NewRequest("POST", "/", bytes.NewReader(gzipped(t, make([]byte, tt.size))))
+			req.Header.Set("Content-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, req)
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+		})
+	}
+}
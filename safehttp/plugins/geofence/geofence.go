@@ -0,0 +1,84 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package geofence provides an interceptor restricting access to the
+// service based on the country requests originate from.
+package geofence
+
+import (
+	"net"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Resolver maps IP addresses to countries.
+type Resolver interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the country the IP
+	// address is located in.
+	Country(ip net.IP) (string, error)
+}
+
+// Interceptor resolves the country of the client, using the remote address of
+// the connection, and rejects requests from Blocked countries with 451
+// Unavailable For Legal Reasons. If Allowed is not empty, requests from
+// countries not listed in it are rejected as well. Requests whose country
+// can't be resolved are rejected with 500 Internal Server Error.
+//
+// Country codes are compared case-insensitively.
+type Interceptor struct {
+	// Resolver resolves the country of the client.
+	Resolver Resolver
+	// Blocked are the countries requests are rejected from.
+	Blocked []string
+	// Allowed are the only countries requests are accepted from, if set.
+	Allowed []string
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if it comes from a country it isn't allowed
+// from.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	host, _, err := net.SplitHostPort(r.RemoteAddr())
+	if err != nil {
+		host = r.RemoteAddr()
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	country, err := it.Resolver.Country(ip)
+	if err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	if contains(it.Blocked, country) || (len(it.Allowed) > 0 && !contains(it.Allowed, country)) {
+		return w.ClientError(safehttp.Status451UnavailableForLegalReasons)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+func contains(countries []string, country string) bool {
+	for _, c := range countries {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geofence
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) Country(ip net.IP) (string, error) {
+	c, ok := f[ip.String()]
+	if !ok {
+		return "", errors.New("unknown address")
+	}
+	return c, nil
+}
+
+func TestGeofence(t *testing.T) {
+	resolver := fakeResolver{
+		"192.0.2.1":   "FR",
+		"192.0.2.2":   "KP",
+		"2001:db8::1": "US",
+	}
+	var tests = []struct {
+		name       string
+		it         Interceptor
+		remoteAddr string
+		wantCode   int
+	}{
+		{
+			name:       "NotBlocked",
+			it:         Interceptor{Resolver: resolver, Blocked: []string{"KP"}},
+			remoteAddr: "192.0.2.1:1234",
+			wantCode:   http.StatusNoContent,
+		},
+		{
+			name:       "Blocked",
+			it:         Interceptor{Resolver: resolver, Blocked: []string{"kp"}},
+			remoteAddr: "192.0.2.2:1234",
+			wantCode:   http.StatusUnavailableForLegalReasons,
+		},
+		{
+			name:       "Allowed",
+			it:         Interceptor{Resolver: resolver, Allowed: []string{"US"}},
+			remoteAddr: "[2001:db8::1]:1234",
+			wantCode:   http.StatusNoContent,
+		},
+		{
+			name:       "NotAllowed",
+			it:         Interceptor{Resolver: resolver, Allowed: []string{"US"}},
+			remoteAddr: "192.0.2.1:1234",
+			wantCode:   http.StatusUnavailableForLegalReasons,
+		},
+		{
+			name:       "Unresolved",
+			it:         Interceptor{Resolver: resolver, Blocked: []string{"KP"}},
+			remoteAddr: "192.0.2.3:1234",
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			})
+			m.Install(tt.it)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
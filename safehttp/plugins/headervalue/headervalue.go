@@ -0,0 +1,50 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package headervalue provides an interceptor limiting the size of
+// individual request header values.
+package headervalue
+
+import "github.com/google/go-safeweb/safehttp"
+
+// Interceptor rejects requests with a header value longer than MaxSize bytes
+// with 431 Request Header Fields Too Large. It complements the limit on the
+// total size of the headers enforced by http.Server.MaxHeaderBytes.
+type Interceptor struct {
+	// MaxSize is the maximum size of a single header value, in bytes. If
+	// zero, the size of header values is not limited.
+	MaxSize int
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if one of its header values is too large.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	if it.MaxSize == 0 {
+		return safehttp.Result{}
+	}
+	for _, name := range r.Header.Names() {
+		for _, v := range r.Header.Values(name) {
+			if len(v) > it.MaxSize {
+				return w.ClientError(safehttp.Status431RequestHeaderFieldsTooLarge)
+			}
+		}
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
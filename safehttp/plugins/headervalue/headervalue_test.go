@@ -0,0 +1,95 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package headervalue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestHeaderValueSize(t *testing.T) {
+	var tests = []struct {
+		name     string
+		header   map[string][]string
+		wantCode int
+	}{
+		{
+			name:     "AtLimit",
+			header:   map[string][]string{"X-Data": {strings.Repeat("a", 16)}},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Oversized",
+			header:   map[string][]string{"X-Data": {strings.Repeat("a", 17)}},
+			wantCode: http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			name:     "OversizedSecondValue",
+			header:   map[string][]string{"X-Data": {"a", strings.Repeat("a", 17)}},
+			wantCode: http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			name: "ManySmallHeaders",
+			header: map[string][]string{
+				"X-A": {strings.Repeat("a", 16)},
+				"X-B": {strings.Repeat("b", 16)},
+				"X-C": {strings.Repeat("c", 16)},
+			},
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			})
+			m.Install(Interceptor{MaxSize: 16})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestZeroMaxSize(t *testing.T) {
+	m := safehttp.NewServeMux(nil)
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	})
+	m.Install(Interceptor{})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Data", strings.Repeat("a", 4096))
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if got, want := rec.Code, http.StatusNoContent; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+}
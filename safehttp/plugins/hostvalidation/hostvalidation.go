@@ -0,0 +1,106 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hostvalidation provides an interceptor rejecting requests whose
+// Host isn't syntactically valid, to protect against attacks relying on
+// malformed hosts being interpreted differently by different components.
+package hostvalidation
+
+import (
+	"net"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor rejects requests with 400 Bad Request unless their Host is a
+// registered name, an IPv4 address or a bracketed IPv6 address as defined by
+// RFC 3986, optionally followed by a port. Empty hosts, IPvFuture literals
+// and IPv6 zone identifiers are rejected.
+type Interceptor struct{}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if its Host is malformed.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	if !ValidHost(r.Host()) {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// ValidHost reports whether host is a valid host, optionally followed by a
+// port, as accepted by the Interceptor.
+func ValidHost(host string) bool {
+	var port string
+	if strings.HasPrefix(host, "[") {
+		end := strings.IndexByte(host, ']')
+		if end < 0 {
+			return false
+		}
+		ip := net.ParseIP(host[1:end])
+		if ip == nil || !strings.Contains(host[1:end], ":") {
+			return false
+		}
+		host, port = "", host[end+1:]
+	} else {
+		if i := strings.IndexByte(host, ':'); i >= 0 {
+			host, port = host[:i], host[i:]
+		}
+		if host == "" || !validRegName(host) {
+			return false
+		}
+	}
+	if port == "" {
+		return true
+	}
+	if port[0] != ':' {
+		return false
+	}
+	for _, c := range port[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validRegName reports whether name matches the reg-name production of
+// RFC 3986, which also covers IPv4 addresses.
+func validRegName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("-._~!$&'()*+,;=", c) >= 0:
+		case c == '%':
+			if i+2 >= len(name) || !isHex(name[i+1]) || !isHex(name[i+2]) {
+				return false
+			}
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
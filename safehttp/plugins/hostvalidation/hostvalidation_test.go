@@ -0,0 +1,67 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostvalidation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestHostValidation(t *testing.T) {
+	var tests = []struct {
+		name     string
+		host     string
+		wantCode int
+	}{
+		{name: "Hostname", host: "example.com", wantCode: http.StatusNoContent},
+		{name: "HostnameWithPort", host: "example.com:8080", wantCode: http.StatusNoContent},
+		{name: "PercentEncoded", host: "ex%41mple.com", wantCode: http.StatusNoContent},
+		{name: "IPv4", host: "192.0.2.1", wantCode: http.StatusNoContent},
+		{name: "IPv6", host: "[2001:db8::1]", wantCode: http.StatusNoContent},
+		{name: "IPv6WithPort", host: "[2001:db8::1]:443", wantCode: http.StatusNoContent},
+		{name: "Empty", host: "", wantCode: http.StatusBadRequest},
+		{name: "InvalidCharacter", host: "exa mple.com", wantCode: http.StatusBadRequest},
+		{name: "Userinfo", host: "user@example.com", wantCode: http.StatusBadRequest},
+		{name: "BadPercentEncoding", host: "example%2.com", wantCode: http.StatusBadRequest},
+		{name: "BadPort", host: "example.com:80a", wantCode: http.StatusBadRequest},
+		{name: "TwoPorts", host: "example.com:80:80", wantCode: http.StatusBadRequest},
+		{name: "UnbracketedIPv6", host: "2001:db8::1", wantCode: http.StatusBadRequest},
+		{name: "UnterminatedIPv6", host: "[2001:db8::1", wantCode: http.StatusBadRequest},
+		{name: "BracketedIPv4", host: "[192.0.2.1]", wantCode: http.StatusBadRequest},
+		{name: "GarbageAfterIPv6", host: "[::1]x", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			})
+			m.Install(Interceptor{})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
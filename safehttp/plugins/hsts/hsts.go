@@ -0,0 +1,129 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hsts provides an interceptor enabling HTTP Strict Transport
+// Security by setting the Strict-Transport-Security header on responses to
+// secure requests, and optionally redirecting insecure requests to HTTPS.
+//
+// See https://tools.ietf.org/html/rfc6797 for more details.
+package hsts
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// PreloadMinMaxAge is the minimum max-age required by the HSTS preload list.
+const PreloadMinMaxAge = 365 * 24 * time.Hour
+
+// Policy configures HTTP Strict Transport Security.
+type Policy struct {
+	// MaxAge is how long the client should only access the host over
+	// HTTPS. It is sent in whole seconds.
+	MaxAge time.Duration
+	// IncludeSubDomains applies the policy to all subdomains of the host.
+	IncludeSubDomains bool
+	// Preload consents to the inclusion of the host in the HSTS preload
+	// list of browsers. It requires IncludeSubDomains and a MaxAge of at
+	// least PreloadMinMaxAge.
+	Preload bool
+	// RedirectHTTP redirects insecure requests to HTTPS with 301 Moved
+	// Permanently, preserving the host, the path and the query.
+	RedirectHTTP bool
+	// BehindProxy makes the interceptor detect insecure requests using the
+	// X-Forwarded-Proto header, for servers behind a proxy terminating TLS.
+	// It must only be set if the proxy overwrites the header.
+	BehindProxy bool
+}
+
+// Interceptor sets the Strict-Transport-Security header on responses to
+// secure requests.
+type Interceptor struct {
+	value        string
+	redirectHTTP bool
+	behindProxy  bool
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// NewInterceptor validates the given policy and creates an interceptor
+// enforcing it.
+func NewInterceptor(p Policy) (Interceptor, error) {
+	if p.MaxAge < 0 {
+		return Interceptor{}, errors.New("negative max age")
+	}
+	if p.Preload && p.MaxAge < PreloadMinMaxAge {
+		return Interceptor{}, fmt.Errorf("max age %v is below the %v required for preloading", p.MaxAge, PreloadMinMaxAge)
+	}
+	if p.Preload && !p.IncludeSubDomains {
+		return Interceptor{}, errors.New("preloading requires including subdomains")
+	}
+	value := fmt.Sprintf("max-age=%d", int64(p.MaxAge/time.Second))
+	if p.IncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if p.Preload {
+		value += "; preload"
+	}
+	return Interceptor{value: value, redirectHTTP: p.RedirectHTTP, behindProxy: p.BehindProxy}, nil
+}
+
+// Before redirects insecure requests to HTTPS, if configured to.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	if !it.redirectHTTP || it.secure(r) {
+		return safehttp.Result{}
+	}
+	u := r.URL()
+	target := url.URL{
+		Scheme:   "https",
+		Host:     r.Host(),
+		Path:     u.Path,
+		RawPath:  u.RawPath,
+		RawQuery: u.RawQuery,
+	}
+	return w.Redirect(r, target.String(), safehttp.Status301MovedPermanently)
+}
+
+// Commit sets the Strict-Transport-Security header on responses to secure
+// requests and marks it as immutable. Clients ignore the header on insecure
+// responses, so it isn't set on them. If the header was already made
+// immutable, it responds with 500 Internal Server Error.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	if !it.secure(r) {
+		return safehttp.Result{}
+	}
+	h := w.Header()
+	if err := h.Set("Strict-Transport-Security", it.value); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	h.MarkImmutable("Strict-Transport-Security")
+	return safehttp.Result{}
+}
+
+// secure reports whether the request was sent over HTTPS by the client.
+func (it Interceptor) secure(r *safehttp.IncomingRequest) bool {
+	if !it.behindProxy {
+		return r.IsTLS()
+	}
+	proto := r.Header.Get("X-Forwarded-Proto")
+	if i := strings.IndexByte(proto, ','); i >= 0 {
+		proto = proto[:i]
+	}
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
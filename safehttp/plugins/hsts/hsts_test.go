@@ -0,0 +1,202 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hsts
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestNewInterceptor(t *testing.T) {
+	var tests = []struct {
+		name      string
+		policy    Policy
+		wantValue string
+		wantErr   bool
+	}{
+		{
+			name:      "MaxAge",
+			policy:    Policy{MaxAge: 90*24*time.Hour + 500*time.Millisecond},
+			wantValue: "max-age=7776000",
+		},
+		{
+			name:      "IncludeSubDomains",
+			policy:    Policy{MaxAge: time.Hour, IncludeSubDomains: true},
+			wantValue: "max-age=3600; includeSubDomains",
+		},
+		{
+			name:      "Preload",
+			policy:    Policy{MaxAge: 2 * PreloadMinMaxAge, IncludeSubDomains: true, Preload: true},
+			wantValue: "max-age=63072000; includeSubDomains; preload",
+		},
+		{
+			name:    "PreloadShortMaxAge",
+			policy:  Policy{MaxAge: 180 * 24 * time.Hour, IncludeSubDomains: true, Preload: true},
+			wantErr: true,
+		},
+		{
+			name:    "PreloadWithoutSubDomains",
+			policy:  Policy{MaxAge: PreloadMinMaxAge, Preload: true},
+			wantErr: true,
+		},
+		{
+			name:    "NegativeMaxAge",
+			policy:  Policy{MaxAge: -time.Second},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it, err := NewInterceptor(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewInterceptor(%+v) got err: %v want err: %v", tt.policy, err, tt.wantErr)
+			}
+			if it.value != tt.wantValue {
+				t.Errorf("it.value got: %q want: %q", it.value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestHSTS(t *testing.T) {
+	var tests = []struct {
+		name         string
+		policy       Policy
+		tls          bool
+		forwarded    string
+		wantCode     int
+		wantHeader   string
+		wantLocation string
+	}{
+		{
+			name:       "TLS",
+			policy:     Policy{MaxAge: time.Hour},
+			tls:        true,
+			wantCode:   http.StatusNoContent,
+			wantHeader: "max-age=3600",
+		},
+		{
+			name:     "PlainHTTP",
+			policy:   Policy{MaxAge: time.Hour},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:         "RedirectPlainHTTP",
+			policy:       Policy{MaxAge: time.Hour, RedirectHTTP: true},
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "https://example.com/a?b=c",
+		},
+		{
+			name:       "BehindProxySecure",
+			policy:     Policy{MaxAge: time.Hour, RedirectHTTP: true, BehindProxy: true},
+			forwarded:  "https",
+			wantCode:   http.StatusNoContent,
+			wantHeader: "max-age=3600",
+		},
+		{
+			name:         "BehindProxyInsecure",
+			policy:       Policy{MaxAge: time.Hour, RedirectHTTP: true, BehindProxy: true},
+			tls:          true,
+			forwarded:    "http",
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "https://example.com/a?b=c",
+		},
+		{
+			name:         "BehindProxyMissingHeader",
+			policy:       Policy{MaxAge: time.Hour, RedirectHTTP: true, BehindProxy: true},
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "https://example.com/a?b=c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it, err := NewInterceptor(tt.policy)
+			if err != nil {
+				t.Fatalf("NewInterceptor() got err: %v want: nil", err)
+			}
+			m := safehttp.NewServeMux(nil)
+			m.Install(it)
+			m.Handle("/a", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			})
+
+			req := httptest.NewRequest("GET", "http://example.com/a?b=c", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.forwarded)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Header().Get("Strict-Transport-Security"); got != tt.wantHeader {
+				t.Errorf(`rec.Header().Get("Strict-Transport-Security") got: %q want: %q`, got, tt.wantHeader)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf(`rec.Header().Get("Location") got: %q want: %q`, got, tt.wantLocation)
+			}
+		})
+	}
+}
+
+type failingCommitInterceptor struct{}
+
+func (failingCommitInterceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+func (failingCommitInterceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return w.ServerError(safehttp.Status500InternalServerError, nil)
+}
+
+func TestHSTSOnCommitError(t *testing.T) {
+	it, err := NewInterceptor(Policy{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("NewInterceptor() got err: %v", err)
+	}
+	m := safehttp.NewServeMux(nil)
+	m.Install(it)
+	// failingCommitInterceptor commits first and replaces the response.
+	m.Install(failingCommitInterceptor{})
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	})
+
+	req := httptest.NewRequest("GET", "https://example.com/", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Strict-Transport-Security"), "max-age=3600"; got != want {
+		t.Errorf(`rec.Header().Get("Strict-Transport-Security") got: %q want: %q`, got, want)
+	}
+	if got, want := rec.Body.String(), "Internal Server Error\n"; got != want {
+		t.Errorf("rec.Body got: %q want: %q", got, want)
+	}
+}
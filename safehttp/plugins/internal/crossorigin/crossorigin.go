@@ -0,0 +1,98 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package crossorigin implements the interceptors of packages coop and coep,
+// which set the Cross-Origin-Opener-Policy and Cross-Origin-Embedder-Policy
+// headers. Both headers share their syntax and only differ in their modes.
+package crossorigin
+
+import (
+	"fmt"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Policy is a cross-origin isolation policy.
+type Policy struct {
+	// Mode is the mode of the policy.
+	Mode string
+	// ReportingGroup is the name of the reporting endpoint violations are
+	// reported to. If empty, violations are not reported.
+	ReportingGroup string
+	// ReportOnly makes the policy report violations without enforcing it,
+	// using the -Report-Only variant of the header.
+	ReportOnly bool
+}
+
+// Interceptor sets the header of a policy on every response.
+type Interceptor struct {
+	name  string
+	value string
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// NewInterceptor validates the policy, whose mode must be one of modes, and
+// creates an interceptor setting it in the given header.
+func NewInterceptor(header string, modes []string, p Policy) (Interceptor, error) {
+	valid := false
+	for _, m := range modes {
+		if p.Mode == m {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return Interceptor{}, fmt.Errorf("invalid mode %q", p.Mode)
+	}
+	value := p.Mode
+	if p.ReportingGroup != "" {
+		if !validGroup(p.ReportingGroup) {
+			return Interceptor{}, fmt.Errorf("invalid reporting group %q", p.ReportingGroup)
+		}
+		value += fmt.Sprintf(`; report-to=%q`, p.ReportingGroup)
+	}
+	if p.ReportOnly {
+		header += "-Report-Only"
+	}
+	return Interceptor{name: header, value: value}, nil
+}
+
+// validGroup reports whether the group can be written as a structured header
+// string without escaping.
+func validGroup(g string) bool {
+	for i := 0; i < len(g); i++ {
+		if c := g[i]; c < 0x20 || c > 0x7e || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// Before is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Commit sets the header of the policy and marks it as immutable. If the
+// header was already made immutable, it responds with 500 Internal Server
+// Error.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	h := w.Header()
+	if err := h.Set(it.name, it.value); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	h.MarkImmutable(it.name)
+	return safehttp.Result{}
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jsondepth provides an interceptor rejecting JSON request bodies
+// nested too deeply, which could exhaust the stack of recursive decoders, or
+// holding batches of too many elements.
+package jsondepth
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// DefaultMaxBytes is the maximum size of the JSON bodies checked by the
+// Interceptor if its MaxBytes is 0.
+const DefaultMaxBytes = 10 << 20
+
+// Interceptor rejects requests with a JSON body whose objects and arrays are
+// nested more than MaxDepth levels deep with 400 Bad Request, and requests
+// with a JSON body larger than MaxBytes or with a top-level JSON array of more
+// than MaxArrayLength elements with 413 Payload Too Large. Bodies of other
+// content types are not checked.
+type Interceptor struct {
+	// MaxDepth is the maximum nesting depth of the JSON body. A scalar value
+	// has depth 0, an empty object or array has depth 1. If 0, the depth is
+	// not limited.
+	MaxDepth int
+	// MaxArrayLength is the maximum number of elements of a JSON body that
+	// is an array, e.g. the requests of a batch endpoint. Nested arrays are
+	// not limited. If 0, the number of elements is not limited.
+	MaxArrayLength int
+	// MaxBytes is the maximum size of the JSON body, which is buffered in
+	// memory to be checked. If 0, DefaultMaxBytes is used.
+	MaxBytes int64
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before checks the nesting depth and the array length of the JSON body of
+// the request. The body is buffered, so that it can still be read by the
+// handler.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	if !isJSON(r.Header.Get("Content-Type")) {
+		return safehttp.Result{}
+	}
+	max := it.MaxBytes
+	if max == 0 {
+		max = DefaultMaxBytes
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body(), max+1))
+	if err != nil {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	if int64(len(body)) > max {
+		return w.ClientError(safehttp.Status413PayloadTooLarge)
+	}
+	r.Body().Close()
+	r.SetBody(ioutil.NopCloser(bytes.NewReader(body)))
+	if it.MaxDepth > 0 && depth(body) > it.MaxDepth {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	if it.MaxArrayLength > 0 && arrayLength(body, it.MaxArrayLength) > it.MaxArrayLength {
+		return w.ClientError(safehttp.Status413PayloadTooLarge)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// isJSON reports whether the media type is application/json or has the +json
+// structured syntax suffix.
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// depth returns the maximum nesting depth of objects and arrays in data,
+// without otherwise validating it.
+func depth(data []byte) int {
+	var cur, max int
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			cur++
+			if cur > max {
+				max = cur
+			}
+		case c == '}' || c == ']':
+			cur--
+		}
+	}
+	return max
+}
+
+// arrayLength returns the number of elements of data if it is an array, or 0
+// otherwise, without otherwise validating it. It stops counting once the
+// number of elements exceeds limit.
+func arrayLength(data []byte, limit int) int {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		return 0
+	}
+	n, cur := 0, 0
+	empty := true
+	inString, escaped := false, false
+	for _, c := range data[1:] {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			continue
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			cur++
+		case c == '}' || c == ']':
+			cur--
+		case c == ',' && cur == 0:
+			n++
+			if n >= limit {
+				return n + 1
+			}
+		}
+		if cur < 0 {
+			break
+		}
+		empty = false
+	}
+	if empty {
+		return 0
+	}
+	return n + 1
+}
@@ -0,0 +1,164 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsondepth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestJSONDepth(t *testing.T) {
+	var tests = []struct {
+		name        string
+		contentType string
+		body        string
+		maxDepth    int
+		wantCode    int
+	}{
+		{
+			name:        "AtLimit",
+			contentType: "application/json",
+			body:        `{"a":[{"b":"[[[["}]}`,
+			maxDepth:    3,
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			name:        "BeyondLimit",
+			contentType: "application/json",
+			body:        `{"a":[{"b":[]}]}`,
+			maxDepth:    3,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "JSONSuffix",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			body:        `[[[[]]]]`,
+			maxDepth:    3,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "NoDepthLimit",
+			contentType: "application/json",
+			body:        `[[[[]]]]`,
+			maxDepth:    0,
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			name:        "TooLarge",
+			contentType: "application/json",
+			body:        `{"a":"` + strings.Repeat("a", 64) + `"}`,
+			maxDepth:    3,
+			wantCode:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "NotJSON",
+			contentType: "text/plain",
+			body:        `[[[[]]]]`,
+			maxDepth:    3,
+			wantCode:    http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", "POST", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				b, err := ioutil.ReadAll(r.Body())
+				if err != nil {
+					t.Errorf("ioutil.ReadAll() got err: %v", err)
+				}
+				got = string(b)
+				return w.NoContent()
+			})
+			m.Install(Interceptor{MaxDepth: tt.maxDepth, MaxBytes: 64})
+
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusNoContent && got != tt.body {
+				t.Errorf("handler body got: %q want: %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestJSONArrayLength(t *testing.T) {
+	var tests = []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "Empty",
+			body:     ` [ ] `,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "AtLimit",
+			body:     `[{"id":1}, {"id":2}, {"id":3}]`,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "AboveLimit",
+			body:     `[{"id":1}, {"id":2}, {"id":3}, {"id":4}]`,
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "NestedElementsNotCounted",
+			body:     `[[1, 2, 3, 4], {"a": [5, 6], "b": 7}, "8, 9"]`,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "EscapedQuotes",
+			body:     `["\",\",\",", "\\", 3, 4]`,
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "Object",
+			body:     `{"a": 1, "b": 2, "c": 3, "d": 4}`,
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/", "POST", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			})
+			m.Install(Interceptor{MaxDepth: 3, MaxArrayLength: 3})
+
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package multipartboundary provides an interceptor rejecting multipart
+// requests with a malformed boundary before their body is parsed.
+package multipartboundary
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// MaxLength is the maximum length of a boundary, as defined by RFC 2046.
+const MaxLength = 70
+
+// Interceptor rejects multipart requests with 400 Bad Request unless their
+// Content-Type specifies a boundary of 1 to MaxLength characters allowed by
+// RFC 2046: letters, digits, spaces (except as the last character) and
+// '()+_,-./:=? characters. Requests with other content types are not
+// checked.
+type Interceptor struct{}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if it is a multipart request with a malformed
+// boundary.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return safehttp.Result{}
+	}
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(ct)), "multipart/") {
+			return w.ClientError(safehttp.Status400BadRequest)
+		}
+		return safehttp.Result{}
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return safehttp.Result{}
+	}
+	if !ValidBoundary(params["boundary"]) {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// ValidBoundary reports whether b is a valid multipart boundary, as accepted
+// by the Interceptor.
+func ValidBoundary(b string) bool {
+	if len(b) == 0 || len(b) > MaxLength || b[len(b)-1] == ' ' {
+		return false
+	}
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("'()+_,-./:=? ", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
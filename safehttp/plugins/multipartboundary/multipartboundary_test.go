@@ -0,0 +1,108 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multipartboundary
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestMultipartBoundary(t *testing.T) {
+	var tests = []struct {
+		name        string
+		contentType string
+		wantCode    int
+	}{
+		{
+			name:        "Valid",
+			contentType: "multipart/form-data; boundary=----WebKitFormBoundary7MA4YWxkTrZu0gW",
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			name:        "QuotedWithSpace",
+			contentType: `multipart/mixed; boundary="simple boundary"`,
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			name:        "MaxLength",
+			contentType: "multipart/form-data; boundary=" + strings.Repeat("a", 70),
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			name:        "TooLong",
+			contentType: "multipart/form-data; boundary=" + strings.Repeat("a", 71),
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "Missing",
+			contentType: "multipart/form-data",
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "Empty",
+			contentType: `multipart/form-data; boundary=""`,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "InvalidCharacter",
+			contentType: `multipart/form-data; boundary="a*b"`,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "TrailingSpace",
+			contentType: `multipart/form-data; boundary="ab "`,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "MalformedMultipart",
+			contentType: "multipart/form-data; boundary",
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "NotMultipart",
+			contentType: "application/x-www-form-urlencoded",
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			name:     "NoContentType",
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/upload", "POST", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			})
+			m.Install(Interceptor{})
+
+			req := httptest.NewRequest("POST", "/upload", nil)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nel provides an interceptor that enables Network Error Logging by
+// setting the NEL and Report-To headers on every response.
+//
+// See https://www.w3.org/TR/network-error-logging/ for more details.
+package nel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Policy configures the reporting of network errors.
+type Policy struct {
+	// Group is the name of the reporting group. If empty, "network-errors"
+	// is used.
+	Group string
+	// Endpoints are the URLs reports are sent to. They must be absolute
+	// HTTPS URLs and at least one is required.
+	Endpoints []string
+	// MaxAge is how long the client should remember the policy.
+	MaxAge time.Duration
+	// IncludeSubdomains applies the policy to all subdomains of the origin.
+	IncludeSubdomains bool
+	// SuccessFraction is the sampling rate of successful requests, between
+	// 0 and 1.
+	SuccessFraction float64
+	// FailureFraction is the sampling rate of failed requests, between 0
+	// and 1.
+	FailureFraction float64
+}
+
+type nelHeader struct {
+	ReportTo          string  `json:"report_to"`
+	MaxAge            int64   `json:"max_age"`
+	IncludeSubdomains bool    `json:"include_subdomains,omitempty"`
+	SuccessFraction   float64 `json:"success_fraction"`
+	FailureFraction   float64 `json:"failure_fraction"`
+}
+
+type reportToHeader struct {
+	Group             string     `json:"group"`
+	MaxAge            int64      `json:"max_age"`
+	Endpoints         []endpoint `json:"endpoints"`
+	IncludeSubdomains bool       `json:"include_subdomains,omitempty"`
+}
+
+type endpoint struct {
+	URL string `json:"url"`
+}
+
+// Interceptor sets the NEL and Report-To headers on every response.
+type Interceptor struct {
+	nel      string
+	reportTo string
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// NewInterceptor validates the given policy and creates an interceptor
+// enforcing it.
+func NewInterceptor(p Policy) (Interceptor, error) {
+	if len(p.Endpoints) == 0 {
+		return Interceptor{}, errors.New("at least one endpoint is required")
+	}
+	if p.MaxAge < 0 {
+		return Interceptor{}, errors.New("negative max age")
+	}
+	if p.SuccessFraction < 0 || p.SuccessFraction > 1 {
+		return Interceptor{}, fmt.Errorf("success fraction %v not in [0, 1]", p.SuccessFraction)
+	}
+	if p.FailureFraction < 0 || p.FailureFraction > 1 {
+		return Interceptor{}, fmt.Errorf("failure fraction %v not in [0, 1]", p.FailureFraction)
+	}
+	group := p.Group
+	if group == "" {
+		group = "network-errors"
+	}
+
+	maxAge := int64(p.MaxAge / time.Second)
+	rt := reportToHeader{
+		Group:             group,
+		MaxAge:            maxAge,
+		IncludeSubdomains: p.IncludeSubdomains,
+	}
+	for _, e := range p.Endpoints {
+		u, err := url.Parse(e)
+		if err != nil {
+			return Interceptor{}, fmt.Errorf("invalid endpoint %q: %v", e, err)
+		}
+		if u.Scheme != "https" || u.Host == "" {
+			return Interceptor{}, fmt.Errorf("endpoint %q is not an absolute https URL", e)
+		}
+		rt.Endpoints = append(rt.Endpoints, endpoint{URL: u.String()})
+	}
+	reportTo, err := json.Marshal(rt)
+	if err != nil {
+		return Interceptor{}, err
+	}
+
+	nel, err := json.Marshal(nelHeader{
+		ReportTo:          group,
+		MaxAge:            maxAge,
+		IncludeSubdomains: p.IncludeSubdomains,
+		SuccessFraction:   p.SuccessFraction,
+		FailureFraction:   p.FailureFraction,
+	})
+	if err != nil {
+		return Interceptor{}, err
+	}
+	return Interceptor{nel: string(nel), reportTo: string(reportTo)}, nil
+}
+
+// Before sets the NEL and Report-To headers and marks them as immutable. If
+// either header was already made immutable, it responds with 500 Internal
+// Server Error.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	h := w.Header()
+	if err := h.Set("NEL", it.nel); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	h.MarkImmutable("NEL")
+	if err := h.Set("Report-To", it.reportTo); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	h.MarkImmutable("Report-To")
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nel
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func TestHeaders(t *testing.T) {
+	it, err := NewInterceptor(Policy{
+		Group:             "nel",
+		Endpoints:         []string{"https://reports.example.com/nel"},
+		MaxAge:            24 * time.Hour,
+		IncludeSubdomains: true,
+		SuccessFraction:   0.1,
+		FailureFraction:   1,
+	})
+	if err != nil {
+		t.Fatalf("NewInterceptor() got err: %v want: nil", err)
+	}
+	m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+		return rw.Write(safehtml.HTMLEscaped("hello"))
+	}, safehttptest.Dispatcher{})
+	m.Install(it)
+
+	rec := httptest.NewRecorder()
+	m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+	var gotNEL map[string]interface{}
+	if err := json.Unmarshal([]byte(rec.Header().Get("NEL")), &gotNEL); err != nil {
+		t.Fatalf("json.Unmarshal(NEL) got err: %v", err)
+	}
+	wantNEL := map[string]interface{}{
+		"report_to":          "nel",
+		"max_age":            float64(86400),
+		"include_subdomains": true,
+		"success_fraction":   0.1,
+		"failure_fraction":   float64(1),
+	}
+	if diff := cmp.Diff(wantNEL, gotNEL); diff != "" {
+		t.Errorf("NEL header mismatch (-want +got):\n%s", diff)
+	}
+
+	var gotReportTo map[string]interface{}
+	if err := json.Unmarshal([]byte(rec.Header().Get("Report-To")), &gotReportTo); err != nil {
+		t.Fatalf("json.Unmarshal(Report-To) got err: %v", err)
+	}
+	wantReportTo := map[string]interface{}{
+		"group":   "nel",
+		"max_age": float64(86400),
+		"endpoints": []interface{}{
+			map[string]interface{}{"url": "https://reports.example.com/nel"},
+		},
+		"include_subdomains": true,
+	}
+	if diff := cmp.Diff(wantReportTo, gotReportTo); diff != "" {
+		t.Errorf("Report-To header mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	var tests = []struct {
+		name   string
+		policy Policy
+	}{
+		{
+			name:   "NoEndpoints",
+			policy: Policy{FailureFraction: 1},
+		},
+		{
+			name:   "RelativeEndpoint",
+			policy: Policy{Endpoints: []string{"/nel"}},
+		},
+		{
+			name:   "InsecureEndpoint",
+			policy: Policy{Endpoints: []string{"http://reports.example.com/nel"}},
+		},
+		{
+			name:   "FractionOutOfRange",
+			policy: Policy{Endpoints: []string{"https://reports.example.com/nel"}, FailureFraction: 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewInterceptor(tt.policy); err == nil {
+				t.Error("NewInterceptor() got: nil want: error")
+			}
+		})
+	}
+}
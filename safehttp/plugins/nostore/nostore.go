@@ -0,0 +1,41 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nostore provides an interceptor preventing error responses from
+// being cached.
+package nostore
+
+import "github.com/google/go-safeweb/safehttp"
+
+// Interceptor sets Cache-Control: no-store on responses with a 4xx or 5xx
+// status code, replacing any caching headers set by the handler.
+type Interceptor struct{}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Commit sets Cache-Control: no-store if the response is an error.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	if w.StatusCode() < 400 {
+		return safehttp.Result{}
+	}
+	if err := w.Header().Set("Cache-Control", "no-store"); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	return safehttp.Result{}
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nostore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestNoStore(t *testing.T) {
+	var tests = []struct {
+		name             string
+		h                safehttp.HandleFunc
+		wantCode         int
+		wantCacheControl string
+	}{
+		{
+			name: "ServerError",
+			h: func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.ServerError(safehttp.Status500InternalServerError, nil)
+			},
+			wantCode:         http.StatusInternalServerError,
+			wantCacheControl: "no-store",
+		},
+		{
+			name: "ClientError",
+			h: func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.ClientError(safehttp.Status404NotFound)
+			},
+			wantCode:         http.StatusNotFound,
+			wantCacheControl: "no-store",
+		},
+		{
+			name: "Success",
+			h: func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			},
+			wantCode:         http.StatusNoContent,
+			wantCacheControl: "public, max-age=600",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				if err := w.Header().Set("Cache-Control", "public, max-age=600"); err != nil {
+					t.Fatalf(`w.Header().Set("Cache-Control") got err: %v`, err)
+				}
+				return tt.h(w, r)
+			}, nil)
+			m.Install(Interceptor{})
+
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf(`rec.Header().Get("Cache-Control") got: %q want: %q`, got, tt.wantCacheControl)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package passwordstrength provides an interceptor rejecting weak passwords
+// submitted to endpoints accepting new credentials, before they reach the
+// handler.
+package passwordstrength
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Rule is a password strength requirement.
+type Rule struct {
+	// Name identifies the rule in error responses.
+	Name string
+	// Check reports whether the password satisfies the rule.
+	Check func(password string) bool
+}
+
+// MinLength requires passwords to have at least n characters.
+func MinLength(n int) Rule {
+	return Rule{
+		Name: fmt.Sprintf("min_length_%d", n),
+		Check: func(p string) bool {
+			return utf8.RuneCountInString(p) >= n
+		},
+	}
+}
+
+// Predefined rules requiring passwords to contain at least one character of
+// a given class.
+var (
+	HasUpper  = containsRule("uppercase", unicode.IsUpper)
+	HasLower  = containsRule("lowercase", unicode.IsLower)
+	HasDigit  = containsRule("digit", unicode.IsDigit)
+	HasSymbol = containsRule("symbol", func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+)
+
+func containsRule(name string, f func(rune) bool) Rule {
+	return Rule{
+		Name: name,
+		Check: func(p string) bool {
+			for _, r := range p {
+				if f(r) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// Interceptor checks the password submitted in the Field of URL-encoded
+// forms against the Rules. Requests with a password failing any rule are
+// rejected with a 400 Bad Request problem response listing the names of the
+// failed rules in its "failed_rules" member. Requests without the field are
+// not checked.
+type Interceptor struct {
+	// Field is the name of the form field containing the password.
+	Field string
+	// Rules are the requirements the password must satisfy.
+	Rules []Rule
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if the submitted password is too weak.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	form, err := r.PostForm()
+	if err != nil {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	passwords, ok := form[it.Field]
+	if !ok {
+		return safehttp.Result{}
+	}
+	var failed []string
+	for _, p := range passwords {
+		failed = append(failed, it.Check(p)...)
+	}
+	if len(failed) == 0 {
+		return safehttp.Result{}
+	}
+	return w.WriteProblem(safehttp.ProblemResponse{
+		Title:      "Password too weak",
+		Status:     safehttp.Status400BadRequest,
+		Extensions: map[string]interface{}{"failed_rules": failed},
+	})
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Check returns the names of the rules the password fails.
+func (it Interceptor) Check(password string) []string {
+	var failed []string
+	for _, rule := range it.Rules {
+		if !rule.Check(password) {
+			failed = append(failed, rule.Name)
+		}
+	}
+	return failed
+}
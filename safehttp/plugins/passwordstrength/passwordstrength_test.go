@@ -0,0 +1,76 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package passwordstrength
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestPasswordStrength(t *testing.T) {
+	var tests = []struct {
+		name     string
+		form     url.Values
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Strong",
+			form:     url.Values{"password": {"Correct-Horse-42"}},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Weak",
+			form:     url.Values{"password": {"horse"}},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"failed_rules":["min_length_8","uppercase","digit"],"status":400,"title":"Password too weak"}` + "\n",
+		},
+		{
+			name:     "NoPassword",
+			form:     url.Values{"username": {"alice"}},
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/register", "POST", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.NoContent()
+			})
+			m.Install(Interceptor{
+				Field: "password",
+				Rules: []Rule{MinLength(8), HasUpper, HasLower, HasDigit},
+			})
+
+			req := httptest.NewRequest("POST", "/register", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+		})
+	}
+}
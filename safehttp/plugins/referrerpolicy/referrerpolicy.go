@@ -0,0 +1,90 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package referrerpolicy provides an interceptor setting the Referrer-Policy
+// header on every response, controlling how much of the URL of the page is
+// sent in the Referer header of the requests it makes.
+//
+// See https://www.w3.org/TR/referrer-policy/ for more details.
+package referrerpolicy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+var policies = map[string]bool{
+	"no-referrer":                     true,
+	"no-referrer-when-downgrade":      true,
+	"same-origin":                     true,
+	"origin":                          true,
+	"strict-origin":                   true,
+	"origin-when-cross-origin":        true,
+	"strict-origin-when-cross-origin": true,
+	"unsafe-url":                      true,
+}
+
+// Interceptor sets the Referrer-Policy header on every response.
+type Interceptor struct {
+	policy string
+}
+
+var _ safehttp.ConfigurableInterceptor = Interceptor{}
+
+// NewInterceptor validates the given policy and creates an interceptor
+// setting it. The policy is either a single policy token, e.g.
+// "strict-origin-when-cross-origin", or a comma-separated list of tokens, in
+// which case browsers use the last one they support.
+func NewInterceptor(policy string) (Interceptor, error) {
+	tokens := strings.Split(policy, ",")
+	for i, t := range tokens {
+		t = strings.TrimSpace(t)
+		if !policies[t] {
+			return Interceptor{}, fmt.Errorf("invalid referrer policy %q", t)
+		}
+		tokens[i] = t
+	}
+	return Interceptor{policy: strings.Join(tokens, ", ")}, nil
+}
+
+// Before is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Commit sets the Referrer-Policy header, overriding any value set by the
+// handler, and marks it as immutable. If the header was already made
+// immutable, it responds with 500 Internal Server Error.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	h := w.Header()
+	if err := h.Set("Referrer-Policy", it.policy); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	h.MarkImmutable("Referrer-Policy")
+	return safehttp.Result{}
+}
+
+// Skip is an InterceptorConfig disabling the interceptor for the requests
+// served by a handler, leaving the Referrer-Policy header to the handler.
+type Skip struct{}
+
+// Configure disables the interceptor when cfg is a Skip.
+func (it Interceptor) Configure(cfg safehttp.InterceptorConfig) (safehttp.Interceptor, bool) {
+	if _, ok := cfg.(Skip); ok {
+		return nil, true
+	}
+	return it, false
+}
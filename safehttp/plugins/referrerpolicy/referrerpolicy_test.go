@@ -0,0 +1,129 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package referrerpolicy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestNewInterceptor(t *testing.T) {
+	var tests = []struct {
+		name       string
+		policy     string
+		wantHeader string
+		wantErr    bool
+	}{
+		{
+			name:       "Single",
+			policy:     "strict-origin-when-cross-origin",
+			wantHeader: "strict-origin-when-cross-origin",
+		},
+		{
+			name:       "Fallback",
+			policy:     "no-referrer,strict-origin-when-cross-origin",
+			wantHeader: "no-referrer, strict-origin-when-cross-origin",
+		},
+		{
+			name:    "Invalid",
+			policy:  "strict-origin-when-crossorigin",
+			wantErr: true,
+		},
+		{
+			name:    "Empty",
+			policy:  "",
+			wantErr: true,
+		},
+		{
+			name:    "UpperCase",
+			policy:  "No-Referrer",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it, err := NewInterceptor(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewInterceptor(%q) got err: %v want err: %v", tt.policy, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if it.policy != tt.wantHeader {
+				t.Errorf("it.policy got: %q want: %q", it.policy, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestReferrerPolicy(t *testing.T) {
+	it, err := NewInterceptor("no-referrer")
+	if err != nil {
+		t.Fatalf("NewInterceptor() got err: %v want: nil", err)
+	}
+	m := safehttp.NewServeMux(nil)
+	m.Install(it)
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		w.Header().Set("Referrer-Policy", "unsafe-url")
+		return w.NoContent()
+	})
+	m.Handle("/embed", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		w.Header().Set("Referrer-Policy", "origin")
+		return w.NoContent()
+	}, Skip{})
+
+	var tests = []struct {
+		path       string
+		wantHeader string
+	}{
+		{path: "/", wantHeader: "no-referrer"},
+		{path: "/embed", wantHeader: "origin"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+		if got, want := rec.Code, http.StatusNoContent; got != want {
+			t.Errorf("GET %s: rec.Code got: %v want: %v", tt.path, got, want)
+		}
+		if got := rec.Header().Get("Referrer-Policy"); got != tt.wantHeader {
+			t.Errorf(`GET %s: rec.Header().Get("Referrer-Policy") got: %q want: %q`, tt.path, got, tt.wantHeader)
+		}
+	}
+}
+
+func TestReferrerPolicyImmutable(t *testing.T) {
+	it, err := NewInterceptor("no-referrer")
+	if err != nil {
+		t.Fatalf("NewInterceptor() got err: %v want: nil", err)
+	}
+	m := safehttp.NewServeMux(nil)
+	m.Install(it)
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		w.Header().MarkImmutable("Referrer-Policy")
+		return w.NoContent()
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+}
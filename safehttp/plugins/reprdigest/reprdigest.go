@@ -0,0 +1,98 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reprdigest provides a response pipeline stage sending the digest
+// of the response representation in the Repr-Digest header to the clients
+// asking for it with Want-Repr-Digest, as defined by RFC 9530.
+package reprdigest
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// algorithms are the supported digest algorithms, in order of preference
+// when the client gives several of them the same weight.
+var algorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha-256", sha256.New},
+	{"sha-512", sha512.New},
+}
+
+// Stage adds a Repr-Digest header to the responses to requests with a
+// Want-Repr-Digest header asking for sha-256 or sha-512. The digest covers
+// the representation as sent, i.e. after its content coding, so the Stage
+// must be added after the stages transforming the body, e.g. compress.Stage
+// (see safehttp.ServeMux.AddResponseStage).
+//
+// Responses to requests without Want-Repr-Digest, or only asking for
+// unsupported algorithms, are left untouched, as are responses in which the
+// Repr-Digest header was made immutable.
+type Stage struct{}
+
+var _ safehttp.ResponseStage = Stage{}
+
+// Transform adds the Repr-Digest header describing b, if it was asked for.
+func (Stage) Transform(b safehttp.ResponseBody) (safehttp.ResponseBody, error) {
+	if b.Request == nil {
+		return b, nil
+	}
+	alg, ok := negotiate(b.Request.Header.Values("Want-Repr-Digest"))
+	if !ok {
+		return b, nil
+	}
+	h := algorithms[alg].new()
+	h.Write(b.Data)
+	value := algorithms[alg].name + "=:" + base64.StdEncoding.EncodeToString(h.Sum(nil)) + ":"
+	// The digest is optional: if it can't be set, the response is still
+	// valid without it.
+	b.SetHeader("Repr-Digest", value)
+	return b, nil
+}
+
+// negotiate returns the index in algorithms of the supported algorithm with
+// the highest weight in the Want-Repr-Digest header values. Weights range
+// from 0, meaning not acceptable, to 10. A member without a weight, e.g.
+// "sha-256", has weight 1. Malformed members are ignored.
+func negotiate(values []string) (int, bool) {
+	weights := map[string]int{}
+	for _, v := range values {
+		for _, member := range strings.Split(v, ",") {
+			name, weight := strings.TrimSpace(member), "1"
+			if i := strings.IndexByte(name, '='); i >= 0 {
+				name, weight = strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+1:])
+			}
+			w, err := strconv.Atoi(weight)
+			if err != nil || w < 0 || w > 10 {
+				continue
+			}
+			weights[strings.ToLower(name)] = w
+		}
+	}
+	best, bestWeight := 0, 0
+	for i, alg := range algorithms {
+		if w := weights[alg.name]; w > bestWeight {
+			best, bestWeight = i, w
+		}
+	}
+	return best, bestWeight > 0
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reprdigest
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/plugins/compress"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func digest(alg string, b []byte) string {
+	switch alg {
+	case "sha-256":
+		sum := sha256.Sum256(b)
+		return alg + "=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+	case "sha-512":
+		sum := sha512.Sum512(b)
+		return alg + "=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+	}
+	return ""
+}
+
+func TestStage(t *testing.T) {
+	var tests = []struct {
+		name    string
+		want    string
+		method  string
+		wantAlg string
+	}{
+		{
+			name:    "Sha256",
+			want:    "sha-256",
+			wantAlg: "sha-256",
+		},
+		{
+			name:    "Weights",
+			want:    "sha-256=3, sha-512=10",
+			wantAlg: "sha-512",
+		},
+		{
+			name:    "SameWeight",
+			want:    "sha-512=5, sha-256=5",
+			wantAlg: "sha-256",
+		},
+		{
+			name:    "NotAcceptable",
+			want:    "sha-256=0, sha-512=1",
+			wantAlg: "sha-512",
+		},
+		{
+			name: "Unsupported",
+			want: "md5=10",
+		},
+		{
+			name: "Malformed",
+			want: "sha-256=high",
+		},
+		{
+			name: "NotAsked",
+		},
+		{
+			name:    "Head",
+			want:    "sha-256",
+			method:  "HEAD",
+			wantAlg: "sha-256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(safehttptest.Dispatcher{})
+			m.AddResponseStage(Stage{})
+			m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				return w.Write(safehtml.HTMLEscaped("hello"))
+			})
+
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			if tt.want != "" {
+				req.Header.Set("Want-Repr-Digest", tt.want)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if got, want := rec.Code, http.StatusOK; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got, want := rec.Header().Get("Repr-Digest"), digest(tt.wantAlg, []byte("hello")); got != want {
+				t.Errorf(`rec.Header().Get("Repr-Digest") got: %q want: %q`, got, want)
+			}
+		})
+	}
+}
+
+func TestStageAfterCompression(t *testing.T) {
+	page := strings.Repeat("Hello, world!\n", 100)
+	m := safehttp.NewServeMux(safehttptest.Dispatcher{})
+	m.AddResponseStage(compress.Stage{})
+	m.AddResponseStage(Stage{})
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		if err := w.Header().Set("Content-Type", "text/plain; charset=utf-8"); err != nil {
+			t.Fatalf("Set(Content-Type) got err: %v", err)
+		}
+		return w.Write(safehtml.HTMLEscaped(page))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Want-Repr-Digest", "sha-256=1")
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if got, want := rec.Header().Get("Content-Encoding"), "gzip"; got != want {
+		t.Errorf(`rec.Header().Get("Content-Encoding") got: %q want: %q`, got, want)
+	}
+	// The digest covers the compressed representation.
+	if got, want := rec.Header().Get("Repr-Digest"), digest("sha-256", rec.Body.Bytes()); got != want {
+		t.Errorf(`rec.Header().Get("Repr-Digest") got: %q want: %q`, got, want)
+	}
+}
+
+func TestStageImmutableHeader(t *testing.T) {
+	m := safehttp.NewServeMux(safehttptest.Dispatcher{})
+	m.AddResponseStage(Stage{})
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		if err := w.Header().Set("Repr-Digest", "sha-256=:AAAA:"); err != nil {
+			t.Fatalf("Set(Repr-Digest) got err: %v", err)
+		}
+		w.Header().MarkImmutable("Repr-Digest")
+		return w.Write(safehtml.HTMLEscaped("hello"))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Want-Repr-Digest", "sha-256")
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Repr-Digest"), "sha-256=:AAAA:"; got != want {
+		t.Errorf(`rec.Header().Get("Repr-Digest") got: %q want: %q`, got, want)
+	}
+}
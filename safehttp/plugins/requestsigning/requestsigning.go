@@ -0,0 +1,120 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package requestsigning provides an interceptor verifying HMAC signatures
+// computed by clients over a canonical form of their requests, as used for
+// partner webhooks.
+package requestsigning
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+const (
+	// DefaultClientHeader is the header identifying the client when
+	// ClientHeader is empty.
+	DefaultClientHeader = "X-Client-Id"
+	// DefaultSignatureHeader is the header containing the signature when
+	// SignatureHeader is empty.
+	DefaultSignatureHeader = "X-Signature"
+)
+
+// Canonicalizer returns the canonical form of the request that is signed.
+// bodyHash is the hex-encoded SHA-256 hash of the request body.
+type Canonicalizer func(r *safehttp.IncomingRequest, bodyHash string) string
+
+// DefaultCanonicalizer joins the method, the escaped path and query, the Date
+// header and the body hash of the request with newlines.
+func DefaultCanonicalizer(r *safehttp.IncomingRequest, bodyHash string) string {
+	return strings.Join([]string{
+		r.Method(),
+		r.URL().RequestURI(),
+		r.Header.Get("Date"),
+		bodyHash,
+	}, "\n")
+}
+
+// Interceptor verifies that requests carry a hex-encoded HMAC-SHA256 of their
+// canonical form, keyed with the secret of the client they claim to come
+// from. Requests from unknown clients or with an invalid signature are
+// rejected with 401 Unauthorized.
+//
+// The body is buffered to compute its hash, so the interceptor should be
+// combined with a limit on the size of request bodies.
+type Interceptor struct {
+	// Secrets maps client identifiers to their secret keys.
+	Secrets map[string][]byte
+	// ClientHeader is the header identifying the client. Defaults to
+	// DefaultClientHeader.
+	ClientHeader string
+	// SignatureHeader is the header containing the signature. Defaults to
+	// DefaultSignatureHeader.
+	SignatureHeader string
+	// Canonicalize returns the signed form of the request. Defaults to
+	// DefaultCanonicalizer.
+	Canonicalize Canonicalizer
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before verifies the signature of the request. The body is replaced with a
+// buffered copy, so that it can still be read by the handler.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	clientHeader, signatureHeader, canonicalize := it.ClientHeader, it.SignatureHeader, it.Canonicalize
+	if clientHeader == "" {
+		clientHeader = DefaultClientHeader
+	}
+	if signatureHeader == "" {
+		signatureHeader = DefaultSignatureHeader
+	}
+	if canonicalize == nil {
+		canonicalize = DefaultCanonicalizer
+	}
+
+	secret, ok := it.Secrets[r.Header.Get(clientHeader)]
+	if !ok {
+		return w.ClientError(safehttp.Status401Unauthorized)
+	}
+	signature, err := hex.DecodeString(r.Header.Get(signatureHeader))
+	if err != nil {
+		return w.ClientError(safehttp.Status401Unauthorized)
+	}
+
+	body, err := ioutil.ReadAll(r.Body())
+	if err != nil {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	r.Body().Close()
+	r.SetBody(ioutil.NopCloser(bytes.NewReader(body)))
+
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(canonicalize(r, hex.EncodeToString(bodyHash[:]))))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return w.ClientError(safehttp.Status401Unauthorized)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requestsigning
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+const date = "Wed, 21 Oct 2015 07:28:00 GMT"
+
+func sign(secret, method, path, body string) string {
+	bodyHash := sha256.Sum256([]byte(body))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + date + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestRequestSigning(t *testing.T) {
+	const body = `{"event":"paid"}`
+	var tests = []struct {
+		name      string
+		path      string
+		client    string
+		signature string
+		wantCode  int
+	}{
+		{
+			name:      "Signed",
+			path:      "/hooks/payments",
+			client:    "partner",
+			signature: sign("s3cret", "POST", "/hooks/payments", body),
+			wantCode:  http.StatusNoContent,
+		},
+		{
+			name:      "TamperedPath",
+			path:      "/hooks/refunds",
+			client:    "partner",
+			signature: sign("s3cret", "POST", "/hooks/payments", body),
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			name:      "WrongSecret",
+			path:      "/hooks/payments",
+			client:    "partner",
+			signature: sign("other", "POST", "/hooks/payments", body),
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			name:      "UnknownClient",
+			path:      "/hooks/payments",
+			client:    "stranger",
+			signature: sign("s3cret", "POST", "/hooks/payments", body),
+			wantCode:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+				b, err := ioutil.ReadAll(r.Body())
+				if err != nil {
+					t.Errorf("ioutil.ReadAll() got err: %v", err)
+				}
+				got = string(b)
+				return w.NoContent()
+			}
+			m := safehttp.NewServeMux(nil)
+			m.Handle("/hooks/", "POST", h)
+			m.Install(Interceptor{Secrets: map[string][]byte{"partner": []byte("s3cret")}})
+
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(body))
+			req.Header.Set("Date", date)
+			req.Header.Set(DefaultClientHeader, tt.client)
+			req.Header.Set(DefaultSignatureHeader, tt.signature)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusNoContent && got != body {
+				t.Errorf("handler body got: %q want: %q", got, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package requiredheaders provides an interceptor verifying that every
+// response carries a set of security headers. It is meant to be used in
+// integration tests to catch routes that bypass the baseline protections.
+package requiredheaders
+
+import (
+	"log"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor checks that all the Headers are set on every response.
+type Interceptor struct {
+	// Headers are the names of the required headers.
+	Headers []string
+	// Strict makes responses missing a required header fail with 500
+	// Internal Server Error. Otherwise, they are only logged.
+	Strict bool
+	// Logf is used to report responses missing a required header. If nil,
+	// log.Printf is used.
+	Logf func(format string, args ...interface{})
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Commit reports the response if one of the required headers is missing and,
+// in strict mode, replaces it with 500 Internal Server Error.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	var missing []string
+	for _, name := range it.Headers {
+		if len(w.Header().Values(name)) == 0 {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return safehttp.Result{}
+	}
+	logf := it.Logf
+	if logf == nil {
+		logf = log.Printf
+	}
+	logf("requiredheaders: response to %q is missing required headers: %s", r.URL().Path, strings.Join(missing, ", "))
+	if it.Strict {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	return safehttp.Result{}
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredheaders
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/plugins/hsts"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func TestRequiredHeaders(t *testing.T) {
+	var tests = []struct {
+		name     string
+		setCSP   bool
+		strict   bool
+		wantCode int
+		wantLogs int
+	}{
+		{
+			name:     "Present",
+			setCSP:   true,
+			strict:   true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "MissingStrict",
+			strict:   true,
+			wantCode: http.StatusInternalServerError,
+			wantLogs: 1,
+		},
+		{
+			name:     "MissingLenient",
+			wantCode: http.StatusOK,
+			wantLogs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				if tt.setCSP {
+					rw.Header().Set("Content-Security-Policy", "object-src 'none'")
+				}
+				return rw.Write(safehtml.HTMLEscaped("hello"))
+			}, safehttptest.Dispatcher{})
+			var logs []string
+			m.Install(Interceptor{
+				Headers: []string{"Content-Security-Policy"},
+				Strict:  tt.strict,
+				Logf: func(format string, args ...interface{}) {
+					logs = append(logs, fmt.Sprintf(format, args...))
+				},
+			})
+
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if got := len(logs); got != tt.wantLogs {
+				t.Errorf("len(logs) got: %v want: %v, logs: %q", got, tt.wantLogs, logs)
+			}
+		})
+	}
+}
+
+func TestStrictKeepsHeaders(t *testing.T) {
+	h, err := hsts.NewInterceptor(hsts.Policy{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("hsts.NewInterceptor() got err: %v", err)
+	}
+	m := safehttp.NewServeMux(safehttptest.Dispatcher{})
+	m.Install(h)
+	m.Install(Interceptor{
+		Headers: []string{"X-Frame-Options"},
+		Strict:  true,
+		Logf:    func(string, ...interface{}) {},
+	})
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.Write(safehtml.HTMLEscaped("hello"))
+	})
+
+	req := httptest.NewRequest("GET", "https://example.com/", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Strict-Transport-Security"), "max-age=3600"; got != want {
+		t.Errorf(`rec.Header().Get("Strict-Transport-Security") got: %q want: %q`, got, want)
+	}
+	if got, want := rec.Body.String(), "Internal Server Error\n"; got != want {
+		t.Errorf("rec.Body got: %q want: %q", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package samesite provides an interceptor ensuring that every cookie set by
+// the application has an explicit SameSite attribute, instead of relying on
+// the browser default.
+package samesite
+
+import (
+	"net/http"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor applies Default to the cookies set without a SameSite
+// attribute or, if Reject is set, replaces the response with 500 Internal
+// Server Error when there is such a cookie. Rejected cookies are not sent
+// with the error response.
+type Interceptor struct {
+	// Default is the SameSite mode applied to cookies without one. If zero,
+	// http.SameSiteLaxMode is used.
+	Default http.SameSite
+	// Reject makes responses setting a cookie without SameSite fail instead.
+	Reject bool
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Commit applies the policy to the cookies set in the response.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	mode := it.Default
+	if mode == 0 || mode == http.SameSiteDefaultMode {
+		mode = http.SameSiteLaxMode
+	}
+	if it.Reject {
+		missing := w.Header().RemoveCookies(func(c *http.Cookie) bool {
+			return c.SameSite == 0 || c.SameSite == http.SameSiteDefaultMode
+		})
+		if len(missing) != 0 {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+		return safehttp.Result{}
+	}
+	w.Header().ApplySameSiteDefault(mode)
+	return safehttp.Result{}
+}
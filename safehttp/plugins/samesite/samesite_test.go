@@ -0,0 +1,91 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package samesite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func TestSameSite(t *testing.T) {
+	var tests = []struct {
+		name        string
+		it          Interceptor
+		cookie      *http.Cookie
+		wantCode    int
+		wantCookies []string
+	}{
+		{
+			name:        "DefaultApplied",
+			it:          Interceptor{},
+			cookie:      &http.Cookie{Name: "a", Value: "b"},
+			wantCode:    http.StatusOK,
+			wantCookies: []string{"a=b; SameSite=Lax"},
+		},
+		{
+			name:        "ConfiguredDefaultApplied",
+			it:          Interceptor{Default: http.SameSiteStrictMode},
+			cookie:      &http.Cookie{Name: "a", Value: "b"},
+			wantCode:    http.StatusOK,
+			wantCookies: []string{"a=b; SameSite=Strict"},
+		},
+		{
+			name:        "ExplicitKept",
+			it:          Interceptor{Default: http.SameSiteStrictMode},
+			cookie:      &http.Cookie{Name: "a", Value: "b", SameSite: http.SameSiteNoneMode, Secure: true},
+			wantCode:    http.StatusOK,
+			wantCookies: []string{"a=b; Secure; SameSite=None"},
+		},
+		{
+			name:     "Rejected",
+			it:       Interceptor{Reject: true},
+			cookie:   &http.Cookie{Name: "a", Value: "b"},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:        "ExplicitNotRejected",
+			it:          Interceptor{Reject: true},
+			cookie:      &http.Cookie{Name: "a", Value: "b", SameSite: http.SameSiteLaxMode},
+			wantCode:    http.StatusOK,
+			wantCookies: []string{"a=b; SameSite=Lax"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				rw.Header().SetCookie(tt.cookie)
+				return rw.Write(safehtml.HTMLEscaped("hello"))
+			}, safehttptest.Dispatcher{})
+			m.Install(tt.it)
+
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if diff := cmp.Diff(tt.wantCookies, rec.Header()["Set-Cookie"]); diff != "" {
+				t.Errorf(`rec.Header()["Set-Cookie"] mismatch (-want +got):\n%s`, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package securecookie provides an interceptor ensuring that cookies set
+// over TLS connections have the Secure attribute.
+package securecookie
+
+import (
+	"net/http"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor adds the Secure attribute to the cookies set without it in
+// responses sent over TLS or, if Reject is set, replaces such responses with
+// 500 Internal Server Error, without the rejected cookies. Responses sent over
+// plain HTTP, e.g. during local development, are left untouched.
+type Interceptor struct {
+	// Reject makes responses setting a cookie without Secure fail instead.
+	Reject bool
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Commit applies the policy to the cookies set in the response.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	if !r.IsTLS() {
+		return safehttp.Result{}
+	}
+	if !it.Reject {
+		w.Header().ApplySecure()
+		return safehttp.Result{}
+	}
+	insecure := w.Header().RemoveCookies(func(c *http.Cookie) bool {
+		return !c.Secure
+	})
+	if len(insecure) != 0 {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	return safehttp.Result{}
+}
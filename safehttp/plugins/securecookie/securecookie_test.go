@@ -0,0 +1,100 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securecookie
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestSecureCookie(t *testing.T) {
+	var tests = []struct {
+		name        string
+		it          Interceptor
+		tls         bool
+		cookie      *http.Cookie
+		wantCode    int
+		wantCookies []string
+	}{
+		{
+			name:        "AddedOverTLS",
+			tls:         true,
+			cookie:      &http.Cookie{Name: "a", Value: "b"},
+			wantCode:    http.StatusNoContent,
+			wantCookies: []string{"a=b; Secure"},
+		},
+		{
+			name:        "AlreadySecure",
+			tls:         true,
+			cookie:      &http.Cookie{Name: "a", Value: "b", Secure: true},
+			wantCode:    http.StatusNoContent,
+			wantCookies: []string{"a=b; Secure"},
+		},
+		{
+			name:        "PlainHTTP",
+			cookie:      &http.Cookie{Name: "a", Value: "b"},
+			wantCode:    http.StatusNoContent,
+			wantCookies: []string{"a=b"},
+		},
+		{
+			name:     "Rejected",
+			it:       Interceptor{Reject: true},
+			tls:      true,
+			cookie:   &http.Cookie{Name: "a", Value: "b"},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:        "SecureNotRejected",
+			it:          Interceptor{Reject: true},
+			tls:         true,
+			cookie:      &http.Cookie{Name: "a", Value: "b", Secure: true},
+			wantCode:    http.StatusNoContent,
+			wantCookies: []string{"a=b; Secure"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(w safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				if err := w.Header().SetCookie(tt.cookie); err != nil {
+					t.Fatalf("SetCookie() got err: %v", err)
+				}
+				return w.NoContent()
+			}, nil)
+			m.Install(tt.it)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			} else {
+				req.TLS = nil
+			}
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, req)
+
+			if got := rec.Code; got != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.wantCode)
+			}
+			if diff := cmp.Diff(tt.wantCookies, rec.Header()["Set-Cookie"]); diff != "" {
+				t.Errorf(`rec.Header()["Set-Cookie"] mismatch (-want +got):\n%s`, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sequence provides an interceptor enforcing the order of the steps
+// of multi-step flows, e.g. a checkout, so that steps can't be skipped or
+// replayed.
+package sequence
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"sync"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// DefaultHeader is the header carrying the sequence token when Header is
+// empty.
+const DefaultHeader = "Sequence-Token"
+
+// State is the progress of a session through a flow.
+type State struct {
+	// Next is the index of the next step the session may take.
+	Next int
+	// Token must be presented by the client to take the next step.
+	Token string
+}
+
+// Store persists the State of sessions.
+type Store interface {
+	// Load returns the state of the session. ok is false if the session
+	// hasn't started the flow.
+	Load(session string) (s State, ok bool, err error)
+	// Save stores the state of the session.
+	Save(session string, s State) error
+	// CompareAndSwap atomically stores the new state of the session if its
+	// current state is old. swapped is false if the state was changed
+	// concurrently, e.g. by a replayed request.
+	CompareAndSwap(session string, old, new State) (swapped bool, err error)
+}
+
+// Interceptor enforces that the Steps of a flow are requested in order, once
+// each, by every session. Sessions are identified by the value of the
+// SessionCookie.
+//
+// Requests to the first step start, or restart, the flow. Every request to a
+// step gets a new token in the response header, which the client must send
+// in the same header of the request to the following step. Requests to a
+// step out of order or with a wrong token are rejected with 409 Conflict,
+// and requests without a session with 403 Forbidden. Requests to paths that
+// are not steps are not checked.
+type Interceptor struct {
+	// Steps are the paths of the steps, in order.
+	Steps []string
+	// Store persists the progress of sessions.
+	Store Store
+	// SessionCookie is the name of the cookie identifying the session.
+	SessionCookie string
+	// Header carries the sequence token. Defaults to DefaultHeader.
+	Header string
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before validates that the request is for the next step of the session and
+// advances the session to the following one.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	step := -1
+	for i, path := range it.Steps {
+		if path == r.URL().Path {
+			step = i
+			break
+		}
+	}
+	if step < 0 {
+		return safehttp.Result{}
+	}
+	header := it.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+
+	c, err := r.Cookie(it.SessionCookie)
+	if err != nil || c.Value == "" {
+		return w.ClientError(safehttp.Status403Forbidden)
+	}
+	token, err := newToken()
+	if err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	next := State{Next: step + 1, Token: token}
+	if step == 0 {
+		if err := it.Store.Save(c.Value, next); err != nil {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+	} else {
+		s, ok, err := it.Store.Load(c.Value)
+		if err != nil {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+		if !ok || s.Next != step || subtle.ConstantTimeCompare([]byte(s.Token), []byte(r.Header.Get(header))) != 1 {
+			return w.ClientError(safehttp.Status409Conflict)
+		}
+		// The state is only advanced if it wasn't already by a concurrent
+		// request with the same token.
+		swapped, err := it.Store.CompareAndSwap(c.Value, s, next)
+		if err != nil {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+		if !swapped {
+			return w.ClientError(safehttp.Status409Conflict)
+		}
+	}
+	if err := w.Header().Set(header, token); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// MemoryStore is a Store keeping the state of sessions in memory. It is
+// safe for concurrent use.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]State
+}
+
+// Load returns the state of the session.
+func (m *MemoryStore) Load(session string) (State, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.states[session]
+	return s, ok, nil
+}
+
+// Save stores the state of the session.
+func (m *MemoryStore) Save(session string, s State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.states == nil {
+		m.states = map[string]State{}
+	}
+	m.states[session] = s
+	return nil
+}
+
+// CompareAndSwap stores the new state of the session if its current state is
+// old.
+func (m *MemoryStore) CompareAndSwap(session string, old, new State) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.states[session]; !ok || s != old {
+		return false, nil
+	}
+	m.states[session] = new
+	return true, nil
+}
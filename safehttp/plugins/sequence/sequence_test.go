@@ -0,0 +1,154 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sequence
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func newMux() *safehttp.ServeMux {
+	return newMuxWithStore(&MemoryStore{})
+}
+
+func newMuxWithStore(s Store) *safehttp.ServeMux {
+	m := safehttp.NewServeMux(nil)
+	h := func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	}
+	for _, p := range []string{"/checkout/cart", "/checkout/shipping", "/checkout/pay", "/help"} {
+		m.Handle(p, "POST", h)
+	}
+	m.Install(Interceptor{
+		Steps:         []string{"/checkout/cart", "/checkout/shipping", "/checkout/pay"},
+		Store:         s,
+		SessionCookie: "session",
+	})
+	return m
+}
+
+// step requests the path with the session cookie and the token, returning
+// the status code and the token for the next step.
+func step(m *safehttp.ServeMux, path, token string) (int, string) {
+	req := httptest.NewRequest("POST", path, nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+	if token != "" {
+		req.Header.Set(DefaultHeader, token)
+	}
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	return rec.Code, rec.Header().Get(DefaultHeader)
+}
+
+func TestInOrder(t *testing.T) {
+	m := newMux()
+	token := ""
+	for _, p := range []string{"/checkout/cart", "/checkout/shipping", "/checkout/pay"} {
+		var code int
+		code, token = step(m, p, token)
+		if code != http.StatusNoContent {
+			t.Fatalf("%s: code got: %v want: %v", p, code, http.StatusNoContent)
+		}
+		if token == "" {
+			t.Fatalf("%s: got no token", p)
+		}
+	}
+}
+
+func TestSkippedStep(t *testing.T) {
+	m := newMux()
+	_, token := step(m, "/checkout/cart", "")
+	if code, _ := step(m, "/checkout/pay", token); code != http.StatusConflict {
+		t.Errorf("code got: %v want: %v", code, http.StatusConflict)
+	}
+}
+
+func TestReplayedStep(t *testing.T) {
+	m := newMux()
+	_, token := step(m, "/checkout/cart", "")
+	step(m, "/checkout/shipping", token)
+	if code, _ := step(m, "/checkout/shipping", token); code != http.StatusConflict {
+		t.Errorf("code got: %v want: %v", code, http.StatusConflict)
+	}
+}
+
+// barrierStore makes concurrent Loads wait for each other, so that they all
+// see the same state.
+type barrierStore struct {
+	*MemoryStore
+	loads *sync.WaitGroup
+}
+
+func (s barrierStore) Load(session string) (State, bool, error) {
+	st, ok, err := s.MemoryStore.Load(session)
+	s.loads.Done()
+	s.loads.Wait()
+	return st, ok, err
+}
+
+func TestConcurrentReplayedStep(t *testing.T) {
+	const requests = 2
+	loads := &sync.WaitGroup{}
+	loads.Add(requests)
+	m := newMuxWithStore(barrierStore{MemoryStore: &MemoryStore{}, loads: loads})
+	_, token := step(m, "/checkout/cart", "")
+
+	codes := make([]int, requests)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i], _ = step(m, "/checkout/shipping", token)
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Ints(codes)
+	if want := []int{http.StatusNoContent, http.StatusConflict}; codes[0] != want[0] || codes[1] != want[1] {
+		t.Errorf("codes got: %v want: %v", codes, want)
+	}
+}
+
+func TestWrongToken(t *testing.T) {
+	m := newMux()
+	step(m, "/checkout/cart", "")
+	if code, _ := step(m, "/checkout/shipping", "forged"); code != http.StatusConflict {
+		t.Errorf("code got: %v want: %v", code, http.StatusConflict)
+	}
+}
+
+func TestNotAStep(t *testing.T) {
+	m := newMux()
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("POST", "/help", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("rec.Code got: %v want: %v", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestNoSession(t *testing.T) {
+	m := newMux()
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("POST", "/checkout/cart", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("rec.Code got: %v want: %v", rec.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sni provides an interceptor that rejects requests whose Host
+// doesn't match the server name sent in the TLS handshake. This prevents
+// domain fronting, where the TLS connection is established for one domain
+// and the request is then sent to another one.
+package sni
+
+import (
+	"net"
+	"strings"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor rejects requests received over TLS whose Host doesn't match the
+// server name indicated by the client (SNI). Requests that weren't received
+// over a direct TLS connection, or without a server name, are not checked.
+type Interceptor struct {
+	// StatusCode is used to reject mismatching requests. If zero, 421
+	// Misdirected Request is used. It must be a client error status code.
+	StatusCode safehttp.StatusCode
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if its Host doesn't match the TLS server name.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	tls := r.TLS()
+	if tls == nil || tls.ServerName == "" {
+		return safehttp.Result{}
+	}
+	host := r.Host()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if strings.EqualFold(strings.TrimSuffix(host, "."), strings.TrimSuffix(tls.ServerName, ".")) {
+		return safehttp.Result{}
+	}
+	code := it.StatusCode
+	if code == 0 {
+		code = safehttp.Status421MisdirectedRequest
+	}
+	return w.ClientError(code)
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sni
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func TestSNI(t *testing.T) {
+	var tests = []struct {
+		name       string
+		target     string
+		serverName string
+		noTLS      bool
+		it         Interceptor
+		want       int
+	}{
+		{
+			name:       "Match",
+			target:     "https://example.com/",
+			serverName: "example.com",
+			want:       http.StatusOK,
+		},
+		{
+			name:       "MatchWithPortAndCase",
+			target:     "https://EXAMPLE.com:8443/",
+			serverName: "example.com",
+			want:       http.StatusOK,
+		},
+		{
+			name:       "Mismatch",
+			target:     "https://hidden.example.org/",
+			serverName: "example.com",
+			want:       http.StatusMisdirectedRequest,
+		},
+		{
+			name:       "MismatchCustomStatus",
+			target:     "https://hidden.example.org/",
+			serverName: "example.com",
+			it:         Interceptor{StatusCode: safehttp.Status400BadRequest},
+			want:       http.StatusBadRequest,
+		},
+		{
+			name:   "NoServerName",
+			target: "https://hidden.example.org/",
+			want:   http.StatusOK,
+		},
+		{
+			name:   "NoTLS",
+			target: "http://hidden.example.org/",
+			noTLS:  true,
+			want:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				return rw.Write(safehtml.HTMLEscaped("hello"))
+			}, safehttptest.Dispatcher{})
+			m.Install(tt.it)
+
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if !tt.noTLS {
+				req.TLS = &tls.ConnectionState{ServerName: tt.serverName}
+			}
+			rec := httptest.NewRecorder()
+
+			m.HandleRequest(rec, req)
+
+			if got := rec.Code; got != tt.want {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.want)
+			}
+		})
+	}
+}
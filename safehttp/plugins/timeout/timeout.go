@@ -0,0 +1,92 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timeout provides an interceptor bounding the time handlers can
+// spend on a request.
+package timeout
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor gives the context of every request a deadline, Timeout after
+// the request reached the interceptor. Handlers observe the deadline through
+// the context of the request and should stop working when it is done.
+//
+// The deadline is cooperative: the interceptor doesn't cut off handlers that
+// ignore the context, as the response can't be written while they run. Once
+// they return, responses written after the deadline are replaced with 503
+// Service Unavailable, unless they are server errors already, and so are
+// handlers returning without a response after the deadline. The 503 is
+// committed by the other interceptors, so it carries their headers.
+//
+// The context is canceled once a response is written, releasing its timer,
+// or at the latest when the server finishes the request.
+//
+// The timeout can be changed for the requests served by a handler, e.g. a
+// slow report generation endpoint, by registering the handler with a Config.
+type Interceptor struct {
+	// Timeout is the maximum time spent on a request. If 0 or negative,
+	// requests have no deadline.
+	Timeout time.Duration
+}
+
+var _ safehttp.ConfigurableInterceptor = Interceptor{}
+
+type cancelKey struct{}
+
+// Before sets the deadline of the request context.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	if it.Timeout <= 0 {
+		return safehttp.Result{}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), it.Timeout)
+	r.SetContext(context.WithValue(ctx, cancelKey{}, cancel))
+	return safehttp.Result{}
+}
+
+// Commit replaces the response with 503 Service Unavailable if the deadline
+// expired, and cancels the context of the request.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	ctx := r.Context()
+	cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc)
+	if !ok {
+		return safehttp.Result{}
+	}
+	defer cancel()
+	if ctx.Err() == context.DeadlineExceeded && w.StatusCode() < safehttp.Status500InternalServerError {
+		return w.ServerError(safehttp.Status503ServiceUnavailable, nil)
+	}
+	return safehttp.Result{}
+}
+
+// Config is an InterceptorConfig changing the timeout of the requests served
+// by a handler.
+type Config struct {
+	// Timeout replaces the Timeout of the Interceptor. If 0 or negative,
+	// the requests served by the handler have no deadline.
+	Timeout time.Duration
+}
+
+// Configure applies cfg to the interceptor when it is a Config.
+func (it Interceptor) Configure(cfg safehttp.InterceptorConfig) (safehttp.Interceptor, bool) {
+	c, ok := cfg.(Config)
+	if !ok {
+		return it, false
+	}
+	return Interceptor{Timeout: c.Timeout}, true
+}
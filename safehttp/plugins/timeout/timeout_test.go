@@ -0,0 +1,164 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeout
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/plugins/hsts"
+)
+
+func TestTimeout(t *testing.T) {
+	slow := func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		select {
+		case <-r.Context().Done():
+			return safehttp.Result{}
+		case <-time.After(50 * time.Millisecond):
+			return w.NoContent()
+		}
+	}
+	late := func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		<-r.Context().Done()
+		return w.NoContent()
+	}
+	fast := func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	}
+
+	var tests = []struct {
+		name     string
+		h        safehttp.HandleFunc
+		cfgs     []safehttp.InterceptorConfig
+		wantCode int
+	}{
+		{
+			name:     "Fast",
+			h:        fast,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "NoResponse",
+			h:        slow,
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "LateResponse",
+			h:        late,
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "RaisedTimeout",
+			h:        slow,
+			cfgs:     []safehttp.InterceptorConfig{Config{Timeout: time.Minute}},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "NoDeadline",
+			h:        slow,
+			cfgs:     []safehttp.InterceptorConfig{Config{}},
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewServeMux(nil)
+			m.Install(Interceptor{Timeout: time.Millisecond})
+			m.Handle("/", "GET", tt.h, tt.cfgs...)
+
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCanceledAfterResponse(t *testing.T) {
+	var ctx context.Context
+	m := safehttp.NewServeMux(nil)
+	m.Install(Interceptor{Timeout: time.Minute})
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		ctx = r.Context()
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("r.Context().Deadline() got: none want: a deadline")
+		}
+		return w.NoContent()
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := ctx.Err(), context.Canceled; got != want {
+		t.Errorf("ctx.Err() got: %v want: %v", got, want)
+	}
+	if got, want := rec.Code, http.StatusNoContent; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+}
+
+func TestZeroTimeout(t *testing.T) {
+	var deadline bool
+	m := safehttp.NewServeMux(nil)
+	m.Install(Interceptor{})
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		_, deadline = r.Context().Deadline()
+		return w.NoContent()
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("rec.Code got: %v want: %v", rec.Code, http.StatusNoContent)
+	}
+	if deadline {
+		t.Error("request context has a deadline, want none")
+	}
+}
+
+func TestTimeoutKeepsHeaders(t *testing.T) {
+	h, err := hsts.NewInterceptor(hsts.Policy{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("hsts.NewInterceptor() got err: %v", err)
+	}
+	m := safehttp.NewServeMux(nil)
+	m.Install(h)
+	m.Install(Interceptor{Timeout: time.Millisecond})
+	m.Handle("/", "GET", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		<-r.Context().Done()
+		return w.NoContent()
+	})
+
+	req := httptest.NewRequest("GET", "https://example.com/", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if got, want := rec.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Strict-Transport-Security"), "max-age=3600"; got != want {
+		t.Errorf(`rec.Header().Get("Strict-Transport-Security") got: %q want: %q`, got, want)
+	}
+}
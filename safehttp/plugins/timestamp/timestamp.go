@@ -0,0 +1,79 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timestamp provides an interceptor that rejects requests carrying a
+// timestamp too far from the server time. This limits the window in which a
+// signed request can be replayed.
+package timestamp
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor rejects requests whose timestamp is older than MaxAge or lies in
+// the future, with 400 Bad Request. ClockSkew is tolerated in both
+// directions. Requests without a timestamp are rejected as well.
+type Interceptor struct {
+	// Header is the name of the header carrying the timestamp. If empty, the
+	// Date header is used. The value is either an HTTP date or the number of
+	// seconds since the Unix epoch.
+	Header string
+	// MaxAge is the maximum age of a request.
+	MaxAge time.Duration
+	// ClockSkew is the tolerated difference between the client and the
+	// server clocks.
+	ClockSkew time.Duration
+
+	// now is used instead of time.Now if set. Used in tests.
+	now func() time.Time
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before rejects the request if its timestamp is missing, malformed or
+// outside of the allowed window.
+func (it Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	name := it.Header
+	if name == "" {
+		name = "Date"
+	}
+	ts, err := parseTimestamp(r.Header.Get(name))
+	if err != nil {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+
+	now := time.Now()
+	if it.now != nil {
+		now = it.now()
+	}
+	if ts.Before(now.Add(-it.MaxAge-it.ClockSkew)) || ts.After(now.Add(it.ClockSkew)) {
+		return w.ClientError(safehttp.Status400BadRequest)
+	}
+	return safehttp.Result{}
+}
+
+// Commit is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	return safehttp.Result{}
+}
+
+func parseTimestamp(v string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	return safehttp.ParseHTTPDate(v)
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func TestTimestamp(t *testing.T) {
+	now := time.Date(2020, time.July, 1, 12, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{
+			name:  "Fresh",
+			value: now.Add(-time.Minute).Format(http.TimeFormat),
+			want:  http.StatusOK,
+		},
+		{
+			name:  "Stale",
+			value: now.Add(-time.Hour).Format(http.TimeFormat),
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "Future",
+			value: now.Add(time.Hour).Format(http.TimeFormat),
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "FutureWithinSkew",
+			value: now.Add(10 * time.Second).Format(http.TimeFormat),
+			want:  http.StatusOK,
+		},
+		{
+			name:  "Missing",
+			value: "",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "Malformed",
+			value: "yesterday",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:   "CustomHeaderUnixSeconds",
+			header: "X-Timestamp",
+			value:  strconv.FormatInt(now.Add(-time.Minute).Unix(), 10),
+			want:   http.StatusOK,
+		},
+		{
+			name:   "CustomHeaderStale",
+			header: "X-Timestamp",
+			value:  strconv.FormatInt(now.Add(-time.Hour).Unix(), 10),
+			want:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				return rw.Write(safehtml.HTMLEscaped("hello"))
+			}, safehttptest.Dispatcher{})
+			m.Install(Interceptor{
+				Header:    tt.header,
+				MaxAge:    5 * time.Minute,
+				ClockSkew: 30 * time.Second,
+				now:       func() time.Time { return now },
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			name := tt.header
+			if name == "" {
+				name = "Date"
+			}
+			if tt.value != "" {
+				req.Header.Set(name, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			m.HandleRequest(rec, req)
+
+			if got := rec.Code; got != tt.want {
+				t.Errorf("rec.Code got: %v want: %v", got, tt.want)
+			}
+		})
+	}
+}
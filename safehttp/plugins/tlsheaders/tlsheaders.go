@@ -0,0 +1,69 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tlsheaders provides an interceptor guarding against headers that
+// only make sense over TLS being sent over plain HTTP.
+package tlsheaders
+
+import (
+	"log"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Interceptor removes the Strict-Transport-Security header from responses
+// sent over plain HTTP, where clients must ignore it, or, if Reject is set,
+// replaces such responses with 500 Internal Server Error. It also logs a
+// warning for every cookie with the Secure attribute set over plain HTTP, as
+// clients don't store them. Responses sent over TLS are left untouched.
+//
+// It must not be used behind a proxy terminating TLS, as all requests would
+// be considered insecure.
+type Interceptor struct {
+	// Reject makes responses setting Strict-Transport-Security over plain
+	// HTTP fail instead. Responses in which the header was made immutable
+	// always fail, even though the header is still sent with the error
+	// response, as it can't be removed.
+	Reject bool
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// Before is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Commit applies the policy to the headers of responses sent over plain
+// HTTP.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	if r.IsTLS() {
+		return safehttp.Result{}
+	}
+	h := w.Header()
+	for _, c := range h.Cookies() {
+		if c.Secure {
+			log.Printf("tlsheaders: Secure cookie %q set over plain HTTP on %s %s, clients will ignore it", c.Name, r.Method(), r.URL().Path)
+		}
+	}
+	if h.Get("Strict-Transport-Security") == "" {
+		return safehttp.Result{}
+	}
+	// The header is removed even when rejecting, so that it isn't sent with
+	// the error response.
+	if err := h.Del("Strict-Transport-Security"); err != nil || it.Reject {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	return safehttp.Result{}
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsheaders
+
+import (
+	"bytes"
+	"crypto/tls"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/plugins/hsts"
+)
+
+func TestHSTS(t *testing.T) {
+	var tests = []struct {
+		name     string
+		it       Interceptor
+		tls      bool
+		setHSTS  bool
+		wantCode int
+		wantHSTS string
+	}{
+		{
+			name:     "PlainHTTP",
+			setHSTS:  true,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "PlainHTTPRejected",
+			it:       Interceptor{Reject: true},
+			setHSTS:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "PlainHTTPWithoutHSTS",
+			it:       Interceptor{Reject: true},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "TLS",
+			it:       Interceptor{Reject: true},
+			tls:      true,
+			setHSTS:  true,
+			wantCode: http.StatusNoContent,
+			wantHSTS: "max-age=60",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(w safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				if tt.setHSTS {
+					if err := w.Header().Set("Strict-Transport-Security", "max-age=60"); err != nil {
+						t.Fatalf("Set() got err: %v", err)
+					}
+				}
+				return w.NoContent()
+			}, nil)
+			m.Install(tt.it)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("Strict-Transport-Security"); got != tt.wantHSTS {
+				t.Errorf(`rec.Header().Get("Strict-Transport-Security") got: %q want: %q`, got, tt.wantHSTS)
+			}
+		})
+	}
+}
+
+func TestHSTSPluginOverPlainHTTP(t *testing.T) {
+	it, err := hsts.NewInterceptor(hsts.Policy{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("hsts.NewInterceptor() got err: %v", err)
+	}
+	m := safehttp.NewMachinery(func(w safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+		return w.NoContent()
+	}, nil)
+	m.Install(Interceptor{Reject: true})
+	m.Install(it)
+
+	rec := httptest.NewRecorder()
+	m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusNoContent; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf(`rec.Header().Get("Strict-Transport-Security") got: %q want: ""`, got)
+	}
+}
+
+func TestSecureCookieWarning(t *testing.T) {
+	var tests = []struct {
+		name     string
+		tls      bool
+		cookie   *http.Cookie
+		wantWarn bool
+	}{
+		{
+			name:     "SecureOverPlainHTTP",
+			cookie:   &http.Cookie{Name: "session", Value: "x", Secure: true},
+			wantWarn: true,
+		},
+		{
+			name:   "NotSecureOverPlainHTTP",
+			cookie: &http.Cookie{Name: "session", Value: "x"},
+		},
+		{
+			name:   "SecureOverTLS",
+			tls:    true,
+			cookie: &http.Cookie{Name: "session", Value: "x", Secure: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			m := safehttp.NewMachinery(func(w safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				if err := w.Header().SetCookie(tt.cookie); err != nil {
+					t.Fatalf("SetCookie() got err: %v", err)
+				}
+				return w.NoContent()
+			}, nil)
+			m.Install(Interceptor{})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, req)
+
+			if got, want := rec.Code, http.StatusNoContent; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got := strings.Contains(logs.String(), `"session"`); got != tt.wantWarn {
+				t.Errorf("warning logged got: %v want: %v, logs: %q", got, tt.wantWarn, logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version provides an interceptor tagging responses with the version
+// of the application that served them, to help debugging deployments.
+package version
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// defaultInternalNetworks are the loopback and private address ranges.
+var defaultInternalNetworks = []string{
+	"127.0.0.0/8",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::1/128",
+	"fc00::/7",
+}
+
+// Interceptor sets the X-App-Version header on responses.
+type Interceptor struct {
+	version string
+	// internal is nil if the header is sent to all clients.
+	internal []*net.IPNet
+}
+
+var _ safehttp.Interceptor = Interceptor{}
+
+// NewInterceptor creates an interceptor sending the version to all clients.
+func NewInterceptor(version string) Interceptor {
+	return Interceptor{version: version}
+}
+
+// NewInternalInterceptor creates an interceptor sending the version only to
+// clients whose IP address is in one of the given networks, in CIDR
+// notation. If no network is given, the loopback and private address ranges
+// are used.
+//
+// The address of the direct peer is used, so requests forwarded by a proxy
+// running in an internal network are considered internal.
+func NewInternalInterceptor(version string, networks ...string) (Interceptor, error) {
+	if len(networks) == 0 {
+		networks = defaultInternalNetworks
+	}
+	it := Interceptor{version: version}
+	for _, n := range networks {
+		_, ipNet, err := net.ParseCIDR(n)
+		if err != nil {
+			return Interceptor{}, fmt.Errorf("invalid network %q: %v", n, err)
+		}
+		it.internal = append(it.internal, ipNet)
+	}
+	return it, nil
+}
+
+// Before is a no-op, required to satisfy the safehttp.Interceptor interface.
+func (Interceptor) Before(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+	return safehttp.Result{}
+}
+
+// Commit sets the X-App-Version header if the client is allowed to see it.
+func (it Interceptor) Commit(w safehttp.ResponseWriter, r *safehttp.IncomingRequest, resp safehttp.Response) safehttp.Result {
+	if it.internal != nil && !it.isInternal(r.RemoteAddr()) {
+		return safehttp.Result{}
+	}
+	if err := w.Header().Set("X-App-Version", it.version); err != nil {
+		return w.ServerError(safehttp.Status500InternalServerError, nil)
+	}
+	return safehttp.Result{}
+}
+
+func (it Interceptor) isInternal(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range it.internal {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
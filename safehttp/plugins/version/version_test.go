@@ -0,0 +1,87 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/go-safeweb/safehttp/safehttptest"
+	"github.com/google/safehtml"
+)
+
+func TestVersion(t *testing.T) {
+	internal, err := NewInternalInterceptor("v1.2.3")
+	if err != nil {
+		t.Fatalf("NewInternalInterceptor() got err: %v want: nil", err)
+	}
+
+	var tests = []struct {
+		name       string
+		it         Interceptor
+		remoteAddr string
+		want       string
+	}{
+		{
+			name:       "AllClients",
+			it:         NewInterceptor("v1.2.3"),
+			remoteAddr: "203.0.113.1:1234",
+			want:       "v1.2.3",
+		},
+		{
+			name:       "InternalOnlyInternalClient",
+			it:         internal,
+			remoteAddr: "10.1.2.3:1234",
+			want:       "v1.2.3",
+		},
+		{
+			name:       "InternalOnlyExternalClient",
+			it:         internal,
+			remoteAddr: "203.0.113.1:1234",
+			want:       "",
+		},
+		{
+			name:       "InternalOnlyIPv6Loopback",
+			it:         internal,
+			remoteAddr: "[::1]:1234",
+			want:       "v1.2.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := safehttp.NewMachinery(func(rw safehttp.ResponseWriter, _ *safehttp.IncomingRequest) safehttp.Result {
+				return rw.Write(safehtml.HTMLEscaped("hello"))
+			}, safehttptest.Dispatcher{})
+			m.Install(tt.it)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, req)
+
+			if got := rec.Header().Get("X-App-Version"); got != tt.want {
+				t.Errorf(`rec.Header().Get("X-App-Version") got: %q want: %q`, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidNetwork(t *testing.T) {
+	if _, err := NewInternalInterceptor("v1", "10.0.0.0"); err == nil {
+		t.Error(`NewInternalInterceptor("v1", "10.0.0.0") got: nil want: error`)
+	}
+}
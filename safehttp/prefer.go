@@ -0,0 +1,82 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"strings"
+)
+
+// Values of the return preference, as defined in RFC 7240, Section 4.2.
+const (
+	ReturnMinimal        = "minimal"
+	ReturnRepresentation = "representation"
+)
+
+// Preference returns the value of the preference with the given name, as sent
+// in the Prefer headers of the request. The name is matched
+// case-insensitively. Parameters of the preference are ignored. If the
+// preference is sent more than once, the first occurrence is used, as
+// required by RFC 7240, Section 2.
+func (r *IncomingRequest) Preference(name string) (value string, ok bool) {
+	for _, v := range r.Header.Values("Prefer") {
+		for _, p := range splitQuoted(v, ',') {
+			p = strings.TrimSpace(splitQuoted(p, ';')[0])
+			tok, val := p, ""
+			if i := strings.IndexByte(p, '='); i >= 0 {
+				tok, val = strings.TrimSpace(p[:i]), strings.TrimSpace(p[i+1:])
+			}
+			if strings.EqualFold(tok, name) {
+				return strings.Trim(val, `"`), true
+			}
+		}
+	}
+	return "", false
+}
+
+// ApplyReturnPreference looks up the return preference of the request and,
+// if it is either ReturnMinimal or ReturnRepresentation, reports it as
+// applied in the Preference-Applied header of the response. It returns the
+// applied preference, or "" if none was applied.
+//
+// Handlers honoring ReturnMinimal should respond with NoContent.
+func ApplyReturnPreference(w ResponseWriter, r *IncomingRequest) (string, error) {
+	v, ok := r.Preference("return")
+	if !ok || (v != ReturnMinimal && v != ReturnRepresentation) {
+		return "", nil
+	}
+	if err := w.Header().Add("Preference-Applied", "return="+v); err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+// splitQuoted splits s around each instance of sep that is not enclosed in
+// double quotes.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPreference(t *testing.T) {
+	var tests = []struct {
+		name      string
+		values    []string
+		pref      string
+		wantValue string
+		wantOK    bool
+	}{
+		{
+			name:      "Simple",
+			values:    []string{"return=minimal"},
+			pref:      "return",
+			wantValue: "minimal",
+			wantOK:    true,
+		},
+		{
+			name:      "CaseInsensitiveToken",
+			values:    []string{"Return=minimal"},
+			pref:      "return",
+			wantValue: "minimal",
+			wantOK:    true,
+		},
+		{
+			name:      "WithParamsAndOthers",
+			values:    []string{`respond-async, wait=10; foo="a,b", return=representation`},
+			pref:      "return",
+			wantValue: "representation",
+			wantOK:    true,
+		},
+		{
+			name:      "FirstOccurrenceWins",
+			values:    []string{"return=minimal", "return=representation"},
+			pref:      "return",
+			wantValue: "minimal",
+			wantOK:    true,
+		},
+		{
+			name:      "NoValue",
+			values:    []string{"respond-async"},
+			pref:      "respond-async",
+			wantValue: "",
+			wantOK:    true,
+		},
+		{
+			name:   "Missing",
+			values: []string{"respond-async"},
+			pref:   "return",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			for _, v := range tt.values {
+				req.Header.Add("Prefer", v)
+			}
+			ir := newIncomingRequest(req)
+			v, ok := ir.Preference(tt.pref)
+			if v != tt.wantValue || ok != tt.wantOK {
+				t.Errorf("ir.Preference(%q) got: (%q, %v) want: (%q, %v)", tt.pref, v, ok, tt.wantValue, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestApplyReturnPreference(t *testing.T) {
+	var tests = []struct {
+		name        string
+		prefer      string
+		want        string
+		wantApplied string
+	}{
+		{
+			name:        "Minimal",
+			prefer:      "return=minimal",
+			want:        ReturnMinimal,
+			wantApplied: "return=minimal",
+		},
+		{
+			name:        "Representation",
+			prefer:      "return=representation",
+			want:        ReturnRepresentation,
+			wantApplied: "return=representation",
+		},
+		{
+			name:   "Unknown",
+			prefer: "return=everything",
+		},
+		{
+			name: "None",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.prefer != "" {
+				req.Header.Set("Prefer", tt.prefer)
+			}
+			ir := newIncomingRequest(req)
+			rec := httptest.NewRecorder()
+			rw := newResponseWriter(nil, rec, &ir, nil)
+
+			got, err := ApplyReturnPreference(rw, &ir)
+			if err != nil {
+				t.Fatalf("ApplyReturnPreference() got err: %v want: nil", err)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyReturnPreference() got: %q want: %q", got, tt.want)
+			}
+			if got := rec.Header().Get("Preference-Applied"); got != tt.wantApplied {
+				t.Errorf(`rec.Header().Get("Preference-Applied") got: %q want: %q`, got, tt.wantApplied)
+			}
+		})
+	}
+}
+
+func TestNoContent(t *testing.T) {
+	ir := newIncomingRequest(httptest.NewRequest("POST", "/", nil))
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+
+	rw.NoContent()
+
+	if got, want := rec.Code, http.StatusNoContent; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got := rec.Body.String(); got != "" {
+		t.Errorf("rec.Body got: %q want: empty", got)
+	}
+}
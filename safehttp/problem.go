@@ -0,0 +1,89 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// ProblemResponse is an error response in the Problem Details for HTTP APIs
+// format, as defined in RFC 7807. Empty fields are omitted.
+type ProblemResponse struct {
+	// Type is a URI reference identifying the problem type. When omitted,
+	// it is assumed to be "about:blank".
+	Type string `json:"type,omitempty"`
+	// Title is a short, human-readable summary of the problem type.
+	Title string `json:"title,omitempty"`
+	// Status is the status code of the response. It must be a 4xx or 5xx
+	// status code.
+	Status StatusCode `json:"status"`
+	// Detail is a human-readable explanation specific to this occurrence
+	// of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI reference identifying this occurrence of the
+	// problem.
+	Instance string `json:"instance,omitempty"`
+	// Extensions are additional members of the problem details object.
+	// They can't override the members above.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON encodes the problem, including its extension members.
+func (p ProblemResponse) MarshalJSON() ([]byte, error) {
+	// problem has the same fields but not the MarshalJSON method, to avoid
+	// an infinite recursion.
+	type problem ProblemResponse
+	b, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+	members := map[string]interface{}{}
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	var std map[string]interface{}
+	if err := json.Unmarshal(b, &std); err != nil {
+		return nil, err
+	}
+	for k, v := range std {
+		members[k] = v
+	}
+	return json.Marshal(members)
+}
+
+// WriteProblem writes the problem as an application/problem+json response,
+// using its Status as the status code, after running the commit phase of the
+// installed interceptors. It panics if the Status is not an error status
+// code.
+//
+// The problem is encoded before anything is written: if encoding fails, e.g.
+// because of an extension member that can't be encoded, a 500 Internal
+// Server Error is written instead.
+func (w *ResponseWriter) WriteProblem(p ProblemResponse) Result {
+	if p.Status < 400 || p.Status >= 600 {
+		panic("not an error status code")
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		log.Printf("safehttp: encoding problem response: %v", err)
+		return w.ServerError(Status500InternalServerError, nil)
+	}
+	if !w.commitError(p.Status, p) {
+		return Result{}
+	}
+	w.writeJSON(p.Status, "application/problem+json", b)
+	return Result{}
+}
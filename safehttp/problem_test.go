@@ -0,0 +1,141 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteProblem(t *testing.T) {
+	ir := newIncomingRequest(httptest.NewRequest("POST", "/orders", nil))
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+
+	rw.WriteProblem(ProblemResponse{
+		Type:     "https://example.com/probs/invalid-quantity",
+		Title:    "Invalid quantity",
+		Status:   Status400BadRequest,
+		Detail:   "Quantity must be positive.",
+		Instance: "/orders",
+	})
+
+	if got, want := rec.Code, http.StatusBadRequest; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Content-Type"), "application/problem+json"; got != want {
+		t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, want)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() got err: %v", err)
+	}
+	want := map[string]interface{}{
+		"type":     "https://example.com/probs/invalid-quantity",
+		"title":    "Invalid quantity",
+		"status":   float64(400),
+		"detail":   "Quantity must be positive.",
+		"instance": "/orders",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteProblemOmitsEmptyFields(t *testing.T) {
+	ir := newIncomingRequest(httptest.NewRequest("GET", "/", nil))
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+
+	rw.WriteProblem(ProblemResponse{Status: Status404NotFound})
+
+	if got, want := rec.Body.String(), `{"status":404}`+"\n"; got != want {
+		t.Errorf("rec.Body got: %q want: %q", got, want)
+	}
+}
+
+func TestWriteProblemExtensions(t *testing.T) {
+	ir := newIncomingRequest(httptest.NewRequest("GET", "/", nil))
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+
+	rw.WriteProblem(ProblemResponse{
+		Title:  "Out of credit",
+		Status: Status400BadRequest,
+		Extensions: map[string]interface{}{
+			"balance": 30,
+			"status":  200,
+		},
+	})
+
+	want := `{"balance":30,"status":400,"title":"Out of credit"}` + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("rec.Body got: %q want: %q", got, want)
+	}
+}
+
+func TestWriteProblemDeterministic(t *testing.T) {
+	write := func(ext map[string]interface{}) string {
+		ir := newIncomingRequest(httptest.NewRequest("GET", "/", nil))
+		rec := httptest.NewRecorder()
+		rw := newResponseWriter(nil, rec, &ir, nil)
+		rw.WriteProblem(ProblemResponse{Status: Status400BadRequest, Extensions: ext})
+		return rec.Body.String()
+	}
+
+	a := map[string]interface{}{}
+	a["zeta"] = 1
+	a["alpha"] = map[string]interface{}{"y": 1, "x": 2}
+	b := map[string]interface{}{}
+	b["alpha"] = map[string]interface{}{"x": 2, "y": 1}
+	b["zeta"] = 1
+
+	want := `{"alpha":{"x":2,"y":1},"status":400,"zeta":1}` + "\n"
+	if got := write(a); got != want {
+		t.Errorf("first body got: %q want: %q", got, want)
+	}
+	if got := write(b); got != want {
+		t.Errorf("second body got: %q want: %q", got, want)
+	}
+}
+
+func TestWriteProblemEncodingError(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	ir := newIncomingRequest(httptest.NewRequest("GET", "/", nil))
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+
+	rw.WriteProblem(ProblemResponse{
+		Status:     Status400BadRequest,
+		Extensions: map[string]interface{}{"callback": func() {}},
+	})
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Body.String(), "Internal Server Error\n"; got != want {
+		t.Errorf("rec.Body got: %q want: %q", got, want)
+	}
+}
@@ -15,13 +15,17 @@
 package safehttp
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"runtime/debug"
 )
 
 // Machinery TODO
 type Machinery struct {
-	h HandleFunc
-	d Dispatcher
+	h            HandleFunc
+	d            Dispatcher
+	interceptors []Interceptor
 }
 
 // NewMachinery TODO
@@ -29,9 +33,70 @@ func NewMachinery(h HandleFunc, d Dispatcher) *Machinery {
 	return &Machinery{h: h, d: d}
 }
 
+// Install installs the given interceptor. The Before phase of interceptors
+// runs in the order they were installed and the Commit phase in the reverse
+// order, unless they have priorities (see WithPriority).
+func (m *Machinery) Install(i Interceptor) {
+	m.interceptors = installInterceptor(m.interceptors, i)
+}
+
 // HandleRequest TODO
 func (m *Machinery) HandleRequest(w http.ResponseWriter, req *http.Request) {
-	rw := newResponseWriter(m.d, w)
+	handleRequest(m.h, m.d, m.interceptors, requestOptions{}, w, req)
+}
+
+// requestOptions are the settings of a ServeMux applied to every request.
+type requestOptions struct {
+	cookiePolicy   CookiePolicy
+	redirectPolicy RedirectPolicy
+	errorHandler   ErrorHandler
+}
+
+// handleRequest runs the Before phase of the enabled interceptors and then,
+// unless one of them already responded, the handler.
+//
+// If the handler or an interceptor panics, the panic and its stack trace are
+// logged and, unless a response was already written, a plain 500 Internal
+// Server Error is written instead, after the commit phase of the
+// interceptors. No internal details are sent to the client.
+//
+// If the handler returns without writing a response after the deadline of
+// the request context expired, 503 Service Unavailable is written.
+//
+// Finally, the interceptors implementing Finisher are given the status code
+// of the response.
+func handleRequest(h HandleFunc, d Dispatcher, interceptors []Interceptor, opts requestOptions, w http.ResponseWriter, req *http.Request) {
 	ir := newIncomingRequest(req)
-	m.h(rw, &ir)
+	interceptors = enabledInterceptors(interceptors)
+	rw := newResponseWriter(d, w, &ir, interceptors)
+	rw.header.cookiePolicy = opts.cookiePolicy
+	rw.redirectPolicy = opts.redirectPolicy
+	rw.errorHandler = opts.errorHandler
+	// The interceptors are finished once the response is written, including
+	// by the recovery below.
+	defer func() {
+		finish(interceptors, &ir, rw.StatusCode())
+	}()
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		log.Printf("safehttp: panic serving %s %s: %v\n%s", req.Method, req.URL.Path, v, debug.Stack())
+		if !rw.written() {
+			// The error handler might be the one panicking.
+			rw.errorHandler = nil
+			rw.ServerError(Status500InternalServerError, nil)
+		}
+	}()
+	for _, i := range interceptors {
+		i.Before(rw, &ir)
+		if rw.written() {
+			return
+		}
+	}
+	h(rw, &ir)
+	if !rw.written() && ir.Context().Err() == context.DeadlineExceeded {
+		rw.ServerError(Status503ServiceUnavailable, nil)
+	}
 }
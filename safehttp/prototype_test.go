@@ -0,0 +1,89 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestErrorSecurityHeaders(t *testing.T) {
+	var tests = []struct {
+		name string
+		h    HandleFunc
+	}{
+		{
+			name: "ServerError",
+			h: func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.ServerError(Status500InternalServerError, nil)
+			},
+		},
+		{
+			name: "Panic",
+			h: func(w ResponseWriter, r *IncomingRequest) Result {
+				panic("connecting to db: password=hunter2")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			m := NewMachinery(tt.h, nil)
+			m.Install(securityHeadersInterceptor{})
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+			if got, want := rec.Code, http.StatusInternalServerError; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got, want := rec.Header().Get("Strict-Transport-Security"), "max-age=31536000"; got != want {
+				t.Errorf(`rec.Header().Get("Strict-Transport-Security") got: %q want: %q`, got, want)
+			}
+			if got, want := rec.Header().Get("Content-Security-Policy"), "object-src 'none'"; got != want {
+				t.Errorf(`rec.Header().Get("Content-Security-Policy") got: %q want: %q`, got, want)
+			}
+			if got, want := rec.Body.String(), "Internal Server Error\n"; got != want {
+				t.Errorf("rec.Body got: %q want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPanicLogged(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	m := NewMachinery(func(w ResponseWriter, r *IncomingRequest) Result {
+		panic("connecting to db: password=hunter2")
+	}, nil)
+	m.HandleRequest(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+
+	got := logs.String()
+	for _, want := range []string{"GET /orders", "password=hunter2", "goroutine"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log got: %q want it to contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// RedirectAllowlist computes the redirect targets allowed for a request, e.g.
+// the redirect URIs registered by the OAuth client the request comes from.
+type RedirectAllowlist func(r *IncomingRequest) []string
+
+// ValidateRedirect checks that target is one of the redirect targets allowed
+// for the request and returns it parsed.
+//
+// The target must be an absolute URL without user information or fragment.
+// It matches an allowed target if their schemes and hosts are equal, ignoring
+// case, and their paths are identical. The query of the target is not
+// compared, so that parameters like the OAuth state can be added to it.
+func ValidateRedirect(r *IncomingRequest, target string, allowlist RedirectAllowlist) (*url.URL, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect target: %v", err)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return nil, errors.New("redirect target is not an absolute URL")
+	}
+	if u.User != nil || u.Fragment != "" {
+		return nil, errors.New("redirect target contains user information or a fragment")
+	}
+	for _, a := range allowlist(r) {
+		au, err := url.Parse(a)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(au.Scheme, u.Scheme) && strings.EqualFold(au.Host, u.Host) && au.Path == u.Path {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("redirect target %q is not allowed", target)
+}
+
+// RedirectPolicy restricts the targets of ResponseWriter.SafeRedirect, e.g.
+// URLs supplied by users in a "next" parameter, to prevent open redirects.
+// The zero value only allows same-origin targets and falls back to "/".
+type RedirectPolicy struct {
+	// AllowedHosts are the hosts, other than the one of the request, that
+	// absolute http and https targets may point to.
+	AllowedHosts []string
+	// DefaultPath is the path redirected to when the target is not
+	// allowed. If empty, "/" is used.
+	DefaultPath string
+}
+
+// target returns the URL to redirect to for the requested target: target
+// itself, normalized, if the policy allows it and the default path
+// otherwise.
+func (p RedirectPolicy) target(r *IncomingRequest, target string) string {
+	if u, ok := p.check(r, target); ok {
+		return u
+	}
+	if p.DefaultPath == "" {
+		return "/"
+	}
+	return p.DefaultPath
+}
+
+func (p RedirectPolicy) check(r *IncomingRequest, target string) (string, bool) {
+	// Browsers treat backslashes like slashes, e.g. "/\evil.com" like
+	// "//evil.com", and ignore some control characters.
+	if target == "" || strings.ContainsAny(target, "\\\t\r\n") {
+		return "", false
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.User != nil || u.Opaque != "" {
+		return "", false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		ref := r.URL().ResolveReference(u)
+		res := url.URL{Path: cleanPath(ref.Path), RawQuery: ref.RawQuery, Fragment: ref.Fragment}
+		return res.String(), true
+	}
+	// Protocol-relative URLs are rejected, as the scheme of the request is
+	// not known reliably.
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	if !p.allowedHost(r, u) {
+		return "", false
+	}
+	u.Path = cleanPath(u.Path)
+	u.RawPath = ""
+	return u.String(), true
+}
+
+func (p RedirectPolicy) allowedHost(r *IncomingRequest, u *url.URL) bool {
+	if strings.EqualFold(u.Host, r.Host()) {
+		return u.Scheme == "https" || !r.IsTLS()
+	}
+	for _, h := range p.AllowedHosts {
+		if strings.EqualFold(u.Host, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanPath removes the dot segments from p, keeping its trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	cp := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cp != "/" {
+		cp += "/"
+	}
+	return cp
+}
+
+// SafeRedirect responds with a redirect to target if the redirect policy
+// allows it, or to the default path of the policy otherwise, after running
+// the commit phase of the installed interceptors. Relative targets and
+// absolute http or https URLs to the host of the request are allowed, as
+// well as URLs to the hosts allowed by the policy. Protocol-relative URLs,
+// e.g. "//evil.com", are never allowed. Dot segments are removed from the
+// path of the target.
+//
+// The Location header is set through Header, so a 500 Internal Server Error
+// is written instead if it is immutable. It panics if code is not a 3xx
+// status code.
+func (w *ResponseWriter) SafeRedirect(r *IncomingRequest, target string, code StatusCode) Result {
+	if code < 300 || code >= 400 {
+		panic("not a redirect status code")
+	}
+	if err := w.header.Set("Location", w.redirectPolicy.target(r, target)); err != nil {
+		return w.ServerError(Status500InternalServerError, nil)
+	}
+	if !w.commit(code, nil) {
+		return Result{}
+	}
+	w.rw.WriteHeader(int(code))
+	return Result{}
+}
@@ -0,0 +1,254 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateRedirect(t *testing.T) {
+	registered := map[string][]string{
+		"client-a": {"https://a.example.com/callback"},
+		"client-b": {"https://b.example.com/callback"},
+	}
+	allowlist := func(r *IncomingRequest) []string {
+		return registered[r.Header.Get("X-Client-Id")]
+	}
+
+	var tests = []struct {
+		name    string
+		client  string
+		target  string
+		wantErr bool
+	}{
+		{
+			name:   "Allowed",
+			client: "client-a",
+			target: "https://a.example.com/callback",
+		},
+		{
+			name:   "AllowedWithQuery",
+			client: "client-a",
+			target: "https://A.example.com/callback?code=1&state=2",
+		},
+		{
+			name:    "OtherClientTarget",
+			client:  "client-a",
+			target:  "https://b.example.com/callback",
+			wantErr: true,
+		},
+		{
+			name:    "DifferentPath",
+			client:  "client-a",
+			target:  "https://a.example.com/callback/../evil",
+			wantErr: true,
+		},
+		{
+			name:    "Relative",
+			client:  "client-a",
+			target:  "/callback",
+			wantErr: true,
+		},
+		{
+			name:    "UserInfo",
+			client:  "client-a",
+			target:  "https://evil.com@a.example.com/callback",
+			wantErr: true,
+		},
+		{
+			name:    "UnknownClient",
+			client:  "client-c",
+			target:  "https://a.example.com/callback",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("X-Client-Id", tt.client)
+			ir := newIncomingRequest(req)
+
+			_, err := ValidateRedirect(&ir, tt.target, allowlist)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("ValidateRedirect(%q) got err: %v want err: %v", tt.target, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type securityHeadersInterceptor struct{}
+
+func (securityHeadersInterceptor) Before(w ResponseWriter, r *IncomingRequest) Result {
+	return Result{}
+}
+
+func (securityHeadersInterceptor) Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result {
+	h := w.Header()
+	h.Set("Strict-Transport-Security", "max-age=31536000")
+	h.Set("Content-Security-Policy", "object-src 'none'")
+	h.MarkImmutable("Strict-Transport-Security")
+	h.MarkImmutable("Content-Security-Policy")
+	return Result{}
+}
+
+func TestRedirectSecurityHeaders(t *testing.T) {
+	m := NewMachinery(func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.Redirect(r, "/login", Status301MovedPermanently)
+	}, nil)
+	m.Install(securityHeadersInterceptor{})
+
+	rec := httptest.NewRecorder()
+	m.HandleRequest(rec, httptest.NewRequest("GET", "/account", nil))
+
+	if got, want := rec.Code, http.StatusMovedPermanently; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Location"), "/login"; got != want {
+		t.Errorf(`rec.Header().Get("Location") got: %q want: %q`, got, want)
+	}
+	if got, want := rec.Header().Get("Strict-Transport-Security"), "max-age=31536000"; got != want {
+		t.Errorf(`rec.Header().Get("Strict-Transport-Security") got: %q want: %q`, got, want)
+	}
+	if got, want := rec.Header().Get("Content-Security-Policy"), "object-src 'none'"; got != want {
+		t.Errorf(`rec.Header().Get("Content-Security-Policy") got: %q want: %q`, got, want)
+	}
+}
+
+func TestSafeRedirect(t *testing.T) {
+	var tests = []struct {
+		name         string
+		policy       RedirectPolicy
+		tls          bool
+		target       string
+		wantLocation string
+	}{
+		{
+			name:         "Path",
+			target:       "/account?tab=1",
+			wantLocation: "/account?tab=1",
+		},
+		{
+			name:         "RelativePath",
+			target:       "settings",
+			wantLocation: "/login/settings",
+		},
+		{
+			name:         "DotSegments",
+			target:       "/a/../../b/./c/",
+			wantLocation: "/b/c/",
+		},
+		{
+			name:         "EncodedDotSegments",
+			target:       "/a/%2e%2e/%2e%2e/b",
+			wantLocation: "/b",
+		},
+		{
+			name:         "SameHost",
+			target:       "http://example.com/a/../b",
+			wantLocation: "http://example.com/b",
+		},
+		{
+			name:         "SameHostDowngrade",
+			tls:          true,
+			target:       "http://example.com/b",
+			wantLocation: "/",
+		},
+		{
+			name:         "AllowedHost",
+			policy:       RedirectPolicy{AllowedHosts: []string{"accounts.example.com"}},
+			target:       "https://Accounts.example.com/",
+			wantLocation: "https://Accounts.example.com/",
+		},
+		{
+			name:         "OtherHost",
+			target:       "https://evil.com/",
+			wantLocation: "/",
+		},
+		{
+			name:         "OtherHostDefaultPath",
+			policy:       RedirectPolicy{DefaultPath: "/home"},
+			target:       "https://evil.com/",
+			wantLocation: "/home",
+		},
+		{
+			name:         "ProtocolRelative",
+			target:       "//evil.com/",
+			wantLocation: "/",
+		},
+		{
+			name:         "ProtocolRelativeSameHost",
+			target:       "//example.com/",
+			wantLocation: "/",
+		},
+		{
+			name:         "Backslash",
+			target:       "/\\evil.com",
+			wantLocation: "/",
+		},
+		{
+			name:         "JavaScript",
+			target:       "javascript:alert(1)",
+			wantLocation: "/",
+		},
+		{
+			name:         "UserInfo",
+			target:       "http://example.com@evil.com/",
+			wantLocation: "/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewServeMux(nil)
+			m.SetRedirectPolicy(tt.policy)
+			m.Handle("/login/", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.SafeRedirect(r, tt.target, Status303SeeOther)
+			})
+
+			req := httptest.NewRequest("GET", "http://example.com/login/", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if got, want := rec.Code, http.StatusSeeOther; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf(`rec.Header().Get("Location") got: %q want: %q`, got, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestSafeRedirectImmutableLocation(t *testing.T) {
+	m := NewServeMux(nil)
+	m.Handle("/login", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		w.Header().MarkImmutable("Location")
+		return w.SafeRedirect(r, "/account", Status303SeeOther)
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/login", nil))
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+}
@@ -15,7 +15,13 @@
 package safehttp
 
 import (
+	"bytes"
+	"io"
+	"log"
 	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
 )
 
 // ResponseWriter TODO
@@ -27,37 +33,218 @@ type ResponseWriter struct {
 	// security. Otherwise one can easily overwrite
 	// the struct bypassing all our safety guarantees.
 	header Header
+
+	// req and interceptors are needed to run the commit
+	// phase of the interceptors before writing a response.
+	req          *IncomingRequest
+	interceptors []Interceptor
+
+	// state is shared between all copies of the
+	// ResponseWriter, so that a response can only be
+	// written once.
+	state *writeState
+	// code is the status code of the response being
+	// committed, shared like state.
+	code *StatusCode
+	// replacement holds the error response written by an
+	// interceptor during the commit phase, shared like state.
+	replacement *replacement
+
+	// redirectPolicy validates the targets of SafeRedirect.
+	redirectPolicy RedirectPolicy
+	// errorHandler writes error responses, if set.
+	errorHandler ErrorHandler
+	// errorCode is the status code of the error being written by the
+	// errorHandler, which can't be changed to a non-error status code.
+	errorCode StatusCode
 }
 
-func newResponseWriter(d Dispatcher, rw http.ResponseWriter) ResponseWriter {
+type writeState int
+
+const (
+	notWritten writeState = iota
+	committing
+	written
+)
+
+func newResponseWriter(d Dispatcher, rw http.ResponseWriter, req *IncomingRequest, interceptors []Interceptor) ResponseWriter {
 	header := newHeader(rw.Header())
-	return ResponseWriter{d: d, rw: rw, header: header}
+	if pw, ok := rw.(*pipelineWriter); ok {
+		// Response stages must respect the immutable headers.
+		pw.header = header
+	}
+	state := notWritten
+	var code StatusCode
+	return ResponseWriter{
+		d:            d,
+		rw:           rw,
+		header:       header,
+		req:          req,
+		interceptors: interceptors,
+		state:        &state,
+		code:         &code,
+		replacement:  &replacement{},
+	}
+}
+
+// NewResponseWriter creates a ResponseWriter writing to rw with the given
+// Dispatcher, without any interceptors. It is meant for testing handlers in
+// isolation (see package safehttptest): ServeMux and Machinery create the
+// ResponseWriter of each request themselves.
+func NewResponseWriter(d Dispatcher, rw http.ResponseWriter) ResponseWriter {
+	return newResponseWriter(d, rw, nil, nil)
 }
 
 // Result TODO
 type Result struct{}
 
-// Write TODO
+// Write writes the response with the Dispatcher, after running the commit
+// phase of the installed interceptors. The response is rendered first, so
+// that a 500 Internal Server Error can be written instead if the Dispatcher
+// fails.
 func (w *ResponseWriter) Write(resp Response) Result {
-	if err := w.d.Write(w.rw, resp); err != nil {
-		panic("error")
+	br := newBufferedResponse()
+	if err := w.d.Write(br, resp); err != nil {
+		log.Printf("safehttp: writing %T response: %v", resp, err)
+		return w.ServerError(Status500InternalServerError, nil)
+	}
+	if !w.commit(Status200OK, resp) {
+		return Result{}
 	}
+	br.writeTo(w.rw)
 	return Result{}
 }
 
-// WriteTemplate TODO
+// WriteTemplate executes the template with the Dispatcher, after running the
+// commit phase of the installed interceptors. The template is executed
+// first, so that a 500 Internal Server Error can be written instead if it
+// fails.
 func (w *ResponseWriter) WriteTemplate(t Template, data interface{}) Result {
-	if err := w.d.ExecuteTemplate(w.rw, t, data); err != nil {
-		panic("error")
+	br := newBufferedResponse()
+	if err := w.d.ExecuteTemplate(br, t, data); err != nil {
+		log.Printf("safehttp: executing template: %v", err)
+		return w.ServerError(Status500InternalServerError, nil)
+	}
+	if !w.commit(Status200OK, t) {
+		return Result{}
+	}
+	br.writeTo(w.rw)
+	return Result{}
+}
+
+// bufferedResponse holds a response rendered by a Dispatcher until it can be
+// written, once the response is committed.
+type bufferedResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: http.Header{}}
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.header
+}
+
+func (br *bufferedResponse) WriteHeader(code int) {
+	if br.code == 0 {
+		br.code = code
+	}
+}
+
+func (br *bufferedResponse) Write(b []byte) (int, error) {
+	br.WriteHeader(http.StatusOK)
+	return br.body.Write(b)
+}
+
+// writeTo writes the buffered headers, status code and body to rw.
+func (br *bufferedResponse) writeTo(rw http.ResponseWriter) {
+	h := rw.Header()
+	for name, values := range br.header {
+		h[name] = values
+	}
+	if br.code == 0 {
+		br.code = http.StatusOK
+	}
+	rw.WriteHeader(br.code)
+	rw.Write(br.body.Bytes())
+}
+
+// NoContent responds with 204 No Content, after running the commit phase of
+// the installed interceptors.
+func (w *ResponseWriter) NoContent() Result {
+	if !w.commit(Status204NoContent, nil) {
+		return Result{}
 	}
+	w.rw.WriteHeader(int(Status204NoContent))
 	return Result{}
 }
 
-// ServerError TODO
+// Redirect responds with a redirect to the given URL, after running the
+// commit phase of the installed interceptors. The URL may be relative to the
+// request path. It panics if code is not a 3xx status code.
+func (w *ResponseWriter) Redirect(r *IncomingRequest, url string, code StatusCode) Result {
+	if code < 300 || code >= 400 {
+		panic("not a redirect status code")
+	}
+	if !w.commit(code, nil) {
+		return Result{}
+	}
+	http.Redirect(w.rw, r.req, url, int(code))
+	return Result{}
+}
+
+// ClientError writes a response with the given client error status code,
+// using the status text as the body, after running the commit phase of the
+// installed interceptors. It panics if code is not a 4xx status code or if a
+// response was already written.
+func (w *ResponseWriter) ClientError(code StatusCode) Result {
+	if code < 400 || code >= 500 {
+		panic("not a client error status code")
+	}
+	w.writeError(code, nil)
+	return Result{}
+}
+
+// ServerError writes a response with the given server error status code,
+// using the status text as the body, after running the commit phase of the
+// installed interceptors, which are passed resp. resp describes the error,
+// if any, and may be nil. It panics if code is not a 5xx status code or if a
+// response was already written.
 func (w *ResponseWriter) ServerError(code StatusCode, resp Response) Result {
+	if code < 500 || code >= 600 {
+		panic("not a server error status code")
+	}
+	w.writeError(code, resp)
 	return Result{}
 }
 
+// StatusCode returns the status code of the response being written. During
+// the commit phase of the interceptors, it is the status code the response
+// will be sent with. It returns 0 if no response was written yet.
+func (w ResponseWriter) StatusCode() StatusCode {
+	return *w.code
+}
+
+// UpgradeRequired responds with 426 Upgrade Required, asking the client to
+// switch to one of the given protocols, e.g. "TLS/1.2" or "HTTP/2.0", in
+// order of preference. The protocols are listed in the Upgrade header. It
+// panics if no protocol is given.
+func (w *ResponseWriter) UpgradeRequired(protocols ...string) Result {
+	if len(protocols) == 0 {
+		panic("no protocol to upgrade to")
+	}
+	if err := w.header.Set("Upgrade", strings.Join(protocols, ", ")); err != nil {
+		return w.ServerError(Status500InternalServerError, nil)
+	}
+	if err := w.header.Set("Connection", "Upgrade"); err != nil {
+		return w.ServerError(Status500InternalServerError, nil)
+	}
+	return w.ClientError(Status426UpgradeRequired)
+}
+
 // Header returns the collection of headers that will be set
 // on the response. Headers must be set before writing a
 // response (e.g. Write, WriteTemplate).
@@ -65,6 +252,191 @@ func (w ResponseWriter) Header() Header {
 	return w.header
 }
 
+// commit runs the commit phase of the installed interceptors. It returns
+// false if one of them wrote an error response instead, in which case resp
+// must not be written.
+//
+// An error response written by an interceptor during the commit phase
+// replaces resp: the following interceptors commit the error response
+// instead, so that it still carries their headers, e.g. HSTS, and it is
+// written once all of them ran.
+//
+// A panicking interceptor doesn't prevent the following ones from running,
+// as they might need to release resources. The panic is logged and a 500
+// Internal Server Error is written once all of them ran.
+func (w *ResponseWriter) commit(code StatusCode, resp Response) bool {
+	if *w.state != notWritten {
+		panic("ResponseWriter was already written to")
+	}
+	*w.state = committing
+	if w.errorCode != 0 && code < 400 {
+		code = w.errorCode
+	}
+	*w.code = code
+	r := w.replacement
+	r.held = &heldResponse{ResponseWriter: w.rw}
+	panicked := false
+	for k := len(w.interceptors) - 1; k >= 0; k-- {
+		if !w.commitInterceptor(w.interceptors[k], resp) {
+			panicked = true
+		}
+		if r.replaced {
+			resp = r.resp
+		}
+	}
+	*w.state = written
+	if panicked {
+		*w.code = Status500InternalServerError
+		http.Error(w.rw, http.StatusText(int(Status500InternalServerError)), int(Status500InternalServerError))
+		return false
+	}
+	if r.replaced {
+		r.held.writeTo(w.rw)
+		return false
+	}
+	return true
+}
+
+// commitInterceptor runs the commit phase of the interceptor. It returns
+// false if the interceptor panicked. Responses written by the interceptor
+// are held until the commit phase ends.
+func (w *ResponseWriter) commitInterceptor(i Interceptor, resp Response) (ok bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("safehttp: panic in the commit phase of %T: %v\n%s", i, v, debug.Stack())
+			ok = false
+		}
+	}()
+	iw := *w
+	iw.rw = w.replacement.held
+	i.Commit(iw, w.req, resp)
+	return true
+}
+
+// replacement is the error response written by an interceptor during the
+// commit phase, replacing the committed response.
+type replacement struct {
+	replaced bool
+	resp     Response
+	held     *heldResponse
+}
+
+// heldResponse holds the status code and the body of an error response
+// written during the commit phase until the phase ends. Its headers are set
+// on the wrapped ResponseWriter directly, like the ones of the interceptors.
+type heldResponse struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (h *heldResponse) WriteHeader(code int) {
+	if h.code == 0 {
+		h.code = code
+	}
+}
+
+func (h *heldResponse) Write(b []byte) (int, error) {
+	h.WriteHeader(http.StatusOK)
+	return h.body.Write(b)
+}
+
+// reset drops the response held so far, when another one replaces it.
+func (h *heldResponse) reset() {
+	h.code = 0
+	h.body.Reset()
+}
+
+// writeTo writes the held status code and body to rw.
+func (h *heldResponse) writeTo(rw http.ResponseWriter) {
+	rw.WriteHeader(h.code)
+	rw.Write(h.body.Bytes())
+}
+
+// writeBody writes body with the given content type and a 200 OK status
+// code, after running the commit phase of the installed interceptors.
+func (w *ResponseWriter) writeBody(contentType string, body []byte) Result {
+	if !w.commit(Status200OK, nil) {
+		return Result{}
+	}
+	h := w.rw.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.rw.WriteHeader(int(Status200OK))
+	w.rw.Write(body)
+	return Result{}
+}
+
+// writeContent streams size bytes of content with the given content type and
+// a 200 OK status code, after running the commit phase of the installed
+// interceptors.
+func (w *ResponseWriter) writeContent(contentType string, size int64, content io.Reader) Result {
+	if !w.commit(Status200OK, nil) {
+		return Result{}
+	}
+	h := w.rw.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.FormatInt(size, 10))
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.rw.WriteHeader(int(Status200OK))
+	io.CopyN(w.rw, content, size)
+	return Result{}
+}
+
+// commitError runs the commit phase of the installed interceptors before an
+// error response is written. During the commit phase, i.e. when written by
+// an interceptor, the error response replaces the committed one and is held
+// until the phase ends. It returns false if one of the interceptors wrote an
+// error response instead.
+func (w *ResponseWriter) commitError(code StatusCode, resp Response) bool {
+	switch *w.state {
+	case written:
+		panic("ResponseWriter was already written to")
+	case committing:
+		*w.code = code
+		r := w.replacement
+		r.replaced = true
+		r.resp = resp
+		r.held.reset()
+		return true
+	}
+	return w.commit(code, resp)
+}
+
+func (w ResponseWriter) writeError(code StatusCode, resp Response) {
+	if w.errorHandler != nil && *w.state == notWritten {
+		ew := w
+		ew.errorHandler = nil
+		ew.errorCode = code
+		ew.rw = &errorStatusWriter{ResponseWriter: w.rw, code: code}
+		w.errorHandler.HandleError(ew, w.req, code)
+		if w.written() {
+			return
+		}
+		// The error handler didn't write a response.
+	}
+	if !w.commitError(code, resp) {
+		return
+	}
+	http.Error(w.rw, http.StatusText(int(code)), int(code))
+}
+
+// writeJSON writes the JSON body b with the given status code and content
+// type. The response must already be committed, so b must be encoded before,
+// with json.Marshal: map keys are encoded in sorted order, so equal values
+// always produce identical bytes.
+func (w ResponseWriter) writeJSON(code StatusCode, contentType string, b []byte) {
+	h := w.rw.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.rw.WriteHeader(int(code))
+	w.rw.Write(append(b, '\n'))
+}
+
+func (w ResponseWriter) written() bool {
+	return *w.state == written
+}
+
 // Dispatcher TODO
 type Dispatcher interface {
 	Write(rw http.ResponseWriter, resp Response) error
@@ -0,0 +1,146 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestUpgradeRequired(t *testing.T) {
+	ir := newIncomingRequest(httptest.NewRequest("GET", "/", nil))
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+
+	rw.UpgradeRequired("TLS/1.2", "HTTP/1.1")
+
+	if got, want := rec.Code, http.StatusUpgradeRequired; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if got, want := rec.Header().Get("Upgrade"), "TLS/1.2, HTTP/1.1"; got != want {
+		t.Errorf(`rec.Header().Get("Upgrade") got: %q want: %q`, got, want)
+	}
+	if got, want := rec.Header().Get("Connection"), "Upgrade"; got != want {
+		t.Errorf(`rec.Header().Get("Connection") got: %q want: %q`, got, want)
+	}
+}
+
+func TestUpgradeRequiredImmutable(t *testing.T) {
+	ir := newIncomingRequest(httptest.NewRequest("GET", "/", nil))
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(nil, rec, &ir, nil)
+	rw.Header().MarkImmutable("Upgrade")
+
+	rw.UpgradeRequired("TLS/1.2")
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+}
+
+type failingDispatcher struct{}
+
+func (failingDispatcher) Write(rw http.ResponseWriter, resp Response) error {
+	rw.Header().Set("X-Partial", "true")
+	rw.Write([]byte("partial"))
+	return errors.New("rendering failed")
+}
+
+func (failingDispatcher) ExecuteTemplate(rw http.ResponseWriter, t Template, data interface{}) error {
+	rw.Write([]byte("partial"))
+	return errors.New("template failed")
+}
+
+func TestDispatcherError(t *testing.T) {
+	var tests = []struct {
+		name string
+		h    HandleFunc
+	}{
+		{
+			name: "Write",
+			h: func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.Write("response")
+			},
+		},
+		{
+			name: "WriteTemplate",
+			h: func(w ResponseWriter, r *IncomingRequest) Result {
+				return w.WriteTemplate(nil, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			var before, commit int
+			m := NewMachinery(tt.h, failingDispatcher{})
+			m.Install(countingInterceptor{before: &before, commit: &commit})
+			rec := httptest.NewRecorder()
+			m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+			if got, want := rec.Code, http.StatusInternalServerError; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got, want := rec.Body.String(), "Internal Server Error\n"; got != want {
+				t.Errorf("rec.Body got: %q want: %q", got, want)
+			}
+			if got := rec.Header().Get("X-Partial"); got != "" {
+				t.Errorf(`rec.Header().Get("X-Partial") got: %q want: ""`, got)
+			}
+			if commit != 1 {
+				t.Errorf("commit got: %d want: 1", commit)
+			}
+		})
+	}
+}
+
+type responseRecordingInterceptor struct {
+	resp *Response
+}
+
+func (it responseRecordingInterceptor) Before(w ResponseWriter, r *IncomingRequest) Result {
+	return Result{}
+}
+
+func (it responseRecordingInterceptor) Commit(w ResponseWriter, r *IncomingRequest, resp Response) Result {
+	*it.resp = resp
+	return Result{}
+}
+
+func TestServerErrorResponse(t *testing.T) {
+	var got Response
+	m := NewMachinery(func(w ResponseWriter, r *IncomingRequest) Result {
+		return w.ServerError(Status503ServiceUnavailable, "maintenance")
+	}, nil)
+	m.Install(responseRecordingInterceptor{resp: &got})
+	rec := httptest.NewRecorder()
+	m.HandleRequest(rec, httptest.NewRequest("GET", "/", nil))
+
+	if got, want := rec.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("rec.Code got: %v want: %v", got, want)
+	}
+	if want := "maintenance"; got != want {
+		t.Errorf("Commit resp got: %v want: %v", got, want)
+	}
+}
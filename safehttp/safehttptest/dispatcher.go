@@ -0,0 +1,50 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttptest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-safeweb/safehttp"
+	"github.com/google/safehtml"
+	"github.com/google/safehtml/template"
+)
+
+// Dispatcher is a safehttp.Dispatcher for tests. It writes safehtml.HTML
+// responses and executes safehtml templates, and fails on any other response
+// or template.
+type Dispatcher struct{}
+
+var _ safehttp.Dispatcher = Dispatcher{}
+
+// Write writes resp if it is a safehtml.HTML.
+func (Dispatcher) Write(rw http.ResponseWriter, resp safehttp.Response) error {
+	x, ok := resp.(safehtml.HTML)
+	if !ok {
+		return fmt.Errorf("not a safe response type: %T", resp)
+	}
+	_, err := rw.Write([]byte(x.String()))
+	return err
+}
+
+// ExecuteTemplate executes t with data if it is a safehtml template.
+func (Dispatcher) ExecuteTemplate(rw http.ResponseWriter, t safehttp.Template, data interface{}) error {
+	x, ok := t.(*template.Template)
+	if !ok {
+		return fmt.Errorf("not a safe template type: %T", t)
+	}
+	return x.Execute(rw, data)
+}
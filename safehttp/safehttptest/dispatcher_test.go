@@ -0,0 +1,67 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttptest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/safehtml"
+	"github.com/google/safehtml/template"
+)
+
+func TestDispatcher(t *testing.T) {
+	var tests = []struct {
+		name    string
+		write   func(d Dispatcher, rec *httptest.ResponseRecorder) error
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "HTML",
+			write: func(d Dispatcher, rec *httptest.ResponseRecorder) error {
+				return d.Write(rec, safehtml.HTMLEscaped("<b>"))
+			},
+			want: "&lt;b&gt;",
+		},
+		{
+			name: "String",
+			write: func(d Dispatcher, rec *httptest.ResponseRecorder) error {
+				return d.Write(rec, "<b>")
+			},
+			wantErr: true,
+		},
+		{
+			name: "Template",
+			write: func(d Dispatcher, rec *httptest.ResponseRecorder) error {
+				return d.ExecuteTemplate(rec, template.Must(template.New("t").Parse("<b>{{.}}</b>")), "<i>")
+			},
+			want: "<b>&lt;i&gt;</b>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			err := tt.write(Dispatcher{}, rec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got err: %v want err: %v", err, tt.wantErr)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttptest
+
+import (
+	"io"
+	"net/http/httptest"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// ResponseRecorder records the response written by a handler under test
+// through its ResponseWriter, which behaves like the one of a ServeMux
+// without interceptors, including the immutability of headers.
+type ResponseRecorder struct {
+	// ResponseWriter is the ResponseWriter to pass to the handler.
+	safehttp.ResponseWriter
+	rec *httptest.ResponseRecorder
+}
+
+// NewResponseRecorder creates a ResponseRecorder writing responses with the
+// given Dispatcher.
+func NewResponseRecorder(d safehttp.Dispatcher) *ResponseRecorder {
+	rec := httptest.NewRecorder()
+	return &ResponseRecorder{
+		ResponseWriter: safehttp.NewResponseWriter(d, rec),
+		rec:            rec,
+	}
+}
+
+// Status returns the status code of the recorded response. It is 200 OK if
+// no status code was written, like for http.ResponseWriter.
+func (r *ResponseRecorder) Status() safehttp.StatusCode {
+	return safehttp.StatusCode(r.rec.Code)
+}
+
+// Body returns the body of the recorded response.
+func (r *ResponseRecorder) Body() string {
+	return r.rec.Body.String()
+}
+
+// CheckJSON checks that the recorded response has the given Content-Type
+// and a JSON body valid according to the schema, like the CheckJSON
+// function.
+func (r *ResponseRecorder) CheckJSON(contentType string, s Schema) error {
+	return CheckJSON(r.rec.Header(), r.rec.Body.Bytes(), contentType, s)
+}
+
+// NewRequest creates an IncomingRequest for the given method, target and
+// body, like httptest.NewRequest. The target is either a path or an absolute
+// URL, and body may be nil.
+func NewRequest(method, target string, body io.Reader) *safehttp.IncomingRequest {
+	return safehttp.NewIncomingRequest(httptest.NewRequest(method, target, body))
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttptest
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+func TestResponseRecorder(t *testing.T) {
+	handler := func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		body, err := ioutil.ReadAll(r.Body())
+		if err != nil {
+			return w.ServerError(safehttp.Status500InternalServerError, nil)
+		}
+		w.Header().Set("X-Frame-Options", "DENY")
+		return w.WriteJSON(safehttp.JSONResponse{Data: map[string]string{"echo": string(body)}, NoPrefix: true})
+	}
+
+	rr := NewResponseRecorder(nil)
+	handler(rr.ResponseWriter, NewRequest("POST", "/echo", strings.NewReader("hi")))
+
+	if got, want := rr.Status(), safehttp.Status200OK; got != want {
+		t.Errorf("rr.Status() got: %v want: %v", got, want)
+	}
+	if got, want := rr.Header().Get("X-Frame-Options"), "DENY"; got != want {
+		t.Errorf(`rr.Header().Get("X-Frame-Options") got: %q want: %q`, got, want)
+	}
+	if got, want := rr.Header().Get("Content-Type"), "application/json; charset=utf-8"; got != want {
+		t.Errorf(`rr.Header().Get("Content-Type") got: %q want: %q`, got, want)
+	}
+	if got, want := rr.Body(), `{"echo":"hi"}`; got != want {
+		t.Errorf("rr.Body() got: %q want: %q", got, want)
+	}
+}
+
+func TestResponseRecorderImmutableHeader(t *testing.T) {
+	rr := NewResponseRecorder(nil)
+	rr.Header().MarkImmutable("Content-Security-Policy")
+
+	err := rr.Header().Set("Content-Security-Policy", "default-src *")
+	if err == nil {
+		t.Error(`rr.Header().Set("Content-Security-Policy") got: nil err want: error`)
+	}
+}
+
+func TestResponseRecorderError(t *testing.T) {
+	rr := NewResponseRecorder(nil)
+	rr.ClientError(safehttp.Status404NotFound)
+
+	if got, want := rr.Status(), safehttp.Status404NotFound; got != want {
+		t.Errorf("rr.Status() got: %v want: %v", got, want)
+	}
+	if got, want := rr.Body(), "Not Found\n"; got != want {
+		t.Errorf("rr.Body() got: %q want: %q", got, want)
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	r := NewRequest("GET", "https://example.com/a?b=c", nil)
+
+	if got, want := r.Method(), "GET"; got != want {
+		t.Errorf("r.Method() got: %q want: %q", got, want)
+	}
+	if got, want := r.Host(), "example.com"; got != want {
+		t.Errorf("r.Host() got: %q want: %q", got, want)
+	}
+	if got, want := r.URL().Query().Get("b"), "c"; got != want {
+		t.Errorf(`r.URL().Query().Get("b") got: %q want: %q`, got, want)
+	}
+	if r.TLS() == nil {
+		t.Error("r.TLS() got: nil want: non-nil")
+	}
+	if _, err := r.Cookie("session"); !errors.Is(err, safehttp.ErrNoCookie) {
+		t.Errorf(`r.Cookie("session") got err: %v want: ErrNoCookie`, err)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package safehttptest provides utilities for testing safehttp handlers.
+package safehttptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+// Schema describes the expected shape of a JSON value. It supports a small
+// subset of JSON Schema, enough to write concise contract tests for routes.
+type Schema struct {
+	// Type is the JSON type of the value: "object", "array", "string",
+	// "number", "boolean" or "null". If empty, any type is accepted.
+	Type string
+	// Properties are the schemas of the members of an object.
+	Properties map[string]Schema
+	// Required are the members an object must have.
+	Required []string
+	// AdditionalProperties allows objects to have members not listed in
+	// Properties.
+	AdditionalProperties bool
+	// Items is the schema of the elements of an array.
+	Items *Schema
+}
+
+// CheckJSON checks that a response with the given header and body has the
+// given Content-Type and a JSON body valid according to the schema. The
+// safehttp.JSONPrefix is stripped from the body first, if present. It
+// returns an error describing the first mismatch found.
+func CheckJSON(h http.Header, body []byte, contentType string, s Schema) error {
+	if got := h.Get("Content-Type"); got != contentType {
+		return fmt.Errorf("Content-Type got: %q want: %q", got, contentType)
+	}
+	dec := json.NewDecoder(bytes.NewReader(bytes.TrimPrefix(body, []byte(safehttp.JSONPrefix))))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON body: %v", err)
+	}
+	return s.validate("$", v)
+}
+
+func (s Schema) validate(path string, v interface{}) error {
+	if s.Type != "" {
+		if got := jsonType(v); got != s.Type {
+			return fmt.Errorf("%s: type got: %s want: %s", path, got, s.Type)
+		}
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required member %q", path, name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			ps, ok := s.Properties[name]
+			if !ok {
+				if !s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected member %q", path, name)
+				}
+				continue
+			}
+			if err := ps.validate(path+"."+name, v[name]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.Items == nil {
+			return nil
+		}
+		for i, e := range v {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttptest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-safeweb/safehttp"
+)
+
+var problemSchema = Schema{
+	Type: "object",
+	Properties: map[string]Schema{
+		"title":  {Type: "string"},
+		"status": {Type: "number"},
+		"errors": {Type: "array", Items: &Schema{Type: "string"}},
+	},
+	Required: []string{"title", "status"},
+}
+
+func TestCheckJSONEndpoint(t *testing.T) {
+	m := safehttp.NewServeMux(nil)
+	m.Handle("/orders", "POST", func(w safehttp.ResponseWriter, r *safehttp.IncomingRequest) safehttp.Result {
+		return w.WriteProblem(safehttp.ProblemResponse{
+			Title:      "Invalid order",
+			Status:     safehttp.Status400BadRequest,
+			Extensions: map[string]interface{}{"errors": []string{"quantity"}},
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("POST", "/orders", nil))
+
+	if err := CheckJSON(rec.Header(), rec.Body.Bytes(), "application/problem+json", problemSchema); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckJSONPrefix(t *testing.T) {
+	rr := NewResponseRecorder(nil)
+	rr.WriteJSON(safehttp.JSONResponse{Data: map[string]interface{}{"title": "Invalid order", "status": 400}})
+
+	if !strings.HasPrefix(rr.Body(), safehttp.JSONPrefix) {
+		t.Fatalf("rr.Body() got: %q want prefix: %q", rr.Body(), safehttp.JSONPrefix)
+	}
+	if err := rr.CheckJSON("application/json; charset=utf-8", problemSchema); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckJSONMismatch(t *testing.T) {
+	var tests = []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		{
+			name:        "ContentType",
+			contentType: "application/json",
+			body:        `{"title":"a","status":400}`,
+			wantErr:     "Content-Type",
+		},
+		{
+			name:        "InvalidJSON",
+			contentType: "application/problem+json",
+			body:        `{"title":`,
+			wantErr:     "invalid JSON",
+		},
+		{
+			name:        "MissingMember",
+			contentType: "application/problem+json",
+			body:        `{"title":"a"}`,
+			wantErr:     `$: missing required member "status"`,
+		},
+		{
+			name:        "WrongType",
+			contentType: "application/problem+json",
+			body:        `{"title":"a","status":"400"}`,
+			wantErr:     "$.status: type got: string want: number",
+		},
+		{
+			name:        "WrongItemType",
+			contentType: "application/problem+json",
+			body:        `{"title":"a","status":400,"errors":["a",1]}`,
+			wantErr:     "$.errors[1]: type got: number want: string",
+		},
+		{
+			name:        "UnexpectedMember",
+			contentType: "application/problem+json",
+			body:        `{"title":"a","status":400,"stack":"..."}`,
+			wantErr:     `$: unexpected member "stack"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", tt.contentType)
+			rec.WriteString(tt.body)
+
+			err := CheckJSON(rec.Header(), rec.Body.Bytes(), "application/problem+json", problemSchema)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckJSON() got err: %v want err containing: %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MinCookieKeyLength is the minimum length of the keys of a CookieSigner.
+const MinCookieKeyLength = 32
+
+var (
+	// ErrMalformedSignedCookie is returned by CookieSigner.Verify when the
+	// value of the cookie is not in the format produced by
+	// CookieSigner.Sign.
+	ErrMalformedSignedCookie = errors.New("safehttp: malformed signed cookie")
+	// ErrInvalidCookieSignature is returned by CookieSigner.Verify when the
+	// signature of the cookie doesn't match its name and value, e.g. because
+	// the client tampered with it.
+	ErrInvalidCookieSignature = errors.New("safehttp: invalid cookie signature")
+	// ErrExpiredSignedCookie is returned by CookieSigner.Verify when the
+	// cookie has a valid signature but expired.
+	ErrExpiredSignedCookie = errors.New("safehttp: signed cookie expired")
+)
+
+// CookieSigner signs cookies with HMAC-SHA256, so that small pieces of state,
+// e.g. flash messages, can be stored on the client without it being able to
+// forge or alter them. The values aren't encrypted: clients can read them.
+//
+// Signed values have the form base64(payload).base64(mac), using the URL-safe
+// base64 alphabet without padding. The payload holds the expiration time of
+// the cookie and its value. The MAC covers the name of the cookie as well, so
+// that a signed value can't be replayed under another name.
+type CookieSigner struct {
+	key []byte
+	now func() time.Time
+}
+
+// NewCookieSigner creates a CookieSigner with the given secret key, which must
+// be at least MinCookieKeyLength bytes long.
+func NewCookieSigner(key []byte) (*CookieSigner, error) {
+	if len(key) < MinCookieKeyLength {
+		return nil, fmt.Errorf("safehttp: cookie signing key is %d bytes long, want at least %d", len(key), MinCookieKeyLength)
+	}
+	return &CookieSigner{key: append([]byte(nil), key...), now: time.Now}, nil
+}
+
+// Sign returns a copy of the cookie with a signed value, to be set with
+// Header.SetCookie. The signature expires with the cookie, as given by its
+// MaxAge or, if not set, its Expires attribute. Cookies without either are
+// valid forever.
+func (s *CookieSigner) Sign(c *http.Cookie) *http.Cookie {
+	var expires int64
+	switch {
+	case c.MaxAge > 0:
+		expires = s.now().Add(time.Duration(c.MaxAge) * time.Second).Unix()
+	case !c.Expires.IsZero():
+		expires = c.Expires.Unix()
+	}
+	payload := make([]byte, 8, 8+len(c.Value))
+	binary.BigEndian.PutUint64(payload, uint64(expires))
+	payload = append(payload, c.Value...)
+
+	signed := *c
+	signed.Value = base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac(c.Name, payload))
+	return &signed
+}
+
+// Verify authenticates the value of a cookie signed by Sign and returns the
+// original value. The error is ErrMalformedSignedCookie,
+// ErrInvalidCookieSignature or ErrExpiredSignedCookie.
+func (s *CookieSigner) Verify(c *http.Cookie) (string, error) {
+	i := strings.IndexByte(c.Value, '.')
+	if i < 0 {
+		return "", ErrMalformedSignedCookie
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(c.Value[:i])
+	if err != nil || len(payload) < 8 {
+		return "", ErrMalformedSignedCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(c.Value[i+1:])
+	if err != nil {
+		return "", ErrMalformedSignedCookie
+	}
+	if !hmac.Equal(mac, s.mac(c.Name, payload)) {
+		return "", ErrInvalidCookieSignature
+	}
+	if expires := int64(binary.BigEndian.Uint64(payload)); expires != 0 && s.now().Unix() >= expires {
+		return "", ErrExpiredSignedCookie
+	}
+	return string(payload[8:]), nil
+}
+
+// Cookie returns the verified value of the signed cookie with the given name
+// sent in the request. It returns ErrNoCookie if the cookie is not present and
+// the errors of Verify otherwise.
+func (s *CookieSigner) Cookie(r *IncomingRequest, name string) (string, error) {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return s.Verify(c)
+}
+
+func (s *CookieSigner) mac(name string, payload []byte) []byte {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(name))
+	m.Write([]byte{0})
+	m.Write(payload)
+	return m.Sum(nil)
+}
@@ -0,0 +1,182 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCookieKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestNewCookieSignerShortKey(t *testing.T) {
+	if _, err := NewCookieSigner([]byte("short")); err == nil {
+		t.Error("NewCookieSigner(short key) got: nil error want: error")
+	}
+}
+
+func TestCookieSigner(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		name      string
+		cookie    *http.Cookie
+		tamper    func(c *http.Cookie)
+		elapsed   time.Duration
+		wantValue string
+		wantErr   error
+	}{
+		{
+			name:      "Valid",
+			cookie:    &http.Cookie{Name: "flash", Value: "Saved"},
+			elapsed:   24 * time.Hour,
+			wantValue: "Saved",
+		},
+		{
+			name:      "Empty",
+			cookie:    &http.Cookie{Name: "flash"},
+			wantValue: "",
+		},
+		{
+			name:      "NotExpiredMaxAge",
+			cookie:    &http.Cookie{Name: "flash", Value: "Saved", MaxAge: 60},
+			elapsed:   59 * time.Second,
+			wantValue: "Saved",
+		},
+		{
+			name:    "ExpiredMaxAge",
+			cookie:  &http.Cookie{Name: "flash", Value: "Saved", MaxAge: 60},
+			elapsed: time.Minute,
+			wantErr: ErrExpiredSignedCookie,
+		},
+		{
+			name:    "ExpiredExpires",
+			cookie:  &http.Cookie{Name: "flash", Value: "Saved", Expires: now.Add(time.Hour)},
+			elapsed: 2 * time.Hour,
+			wantErr: ErrExpiredSignedCookie,
+		},
+		{
+			name:   "TamperedValue",
+			cookie: &http.Cookie{Name: "flash", Value: "Saved"},
+			tamper: func(c *http.Cookie) {
+				signed := (&CookieSigner{key: testCookieKey, now: func() time.Time { return now }}).Sign(&http.Cookie{Name: "flash", Value: "Deleted"})
+				c.Value = strings.SplitN(signed.Value, ".", 2)[0] + c.Value[strings.IndexByte(c.Value, '.'):]
+			},
+			wantErr: ErrInvalidCookieSignature,
+		},
+		{
+			name:    "RenamedCookie",
+			cookie:  &http.Cookie{Name: "flash", Value: "Saved"},
+			tamper:  func(c *http.Cookie) { c.Name = "other" },
+			wantErr: ErrInvalidCookieSignature,
+		},
+		{
+			name:   "OtherKey",
+			cookie: &http.Cookie{Name: "flash", Value: "Saved"},
+			tamper: func(c *http.Cookie) {
+				s, _ := NewCookieSigner([]byte("fedcba9876543210fedcba9876543210"))
+				c.Value = s.Sign(&http.Cookie{Name: "flash", Value: "Saved"}).Value
+			},
+			wantErr: ErrInvalidCookieSignature,
+		},
+		{
+			name:    "Unsigned",
+			cookie:  &http.Cookie{Name: "flash", Value: "Saved"},
+			tamper:  func(c *http.Cookie) { c.Value = "Saved" },
+			wantErr: ErrMalformedSignedCookie,
+		},
+		{
+			name:    "BadBase64",
+			cookie:  &http.Cookie{Name: "flash", Value: "Saved"},
+			tamper:  func(c *http.Cookie) { c.Value = "!!!." + c.Value },
+			wantErr: ErrMalformedSignedCookie,
+		},
+		{
+			name:    "ShortPayload",
+			cookie:  &http.Cookie{Name: "flash", Value: "Saved"},
+			tamper:  func(c *http.Cookie) { c.Value = "AAAA" + c.Value[strings.IndexByte(c.Value, '.'):] },
+			wantErr: ErrMalformedSignedCookie,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewCookieSigner(testCookieKey)
+			if err != nil {
+				t.Fatalf("NewCookieSigner() got err: %v", err)
+			}
+			s.now = func() time.Time { return now }
+			signed := s.Sign(tt.cookie)
+			if tt.tamper != nil {
+				tt.tamper(signed)
+			}
+			s.now = func() time.Time { return now.Add(tt.elapsed) }
+
+			got, err := s.Verify(signed)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("s.Verify() got err: %v want: %v", err, tt.wantErr)
+			}
+			if got != tt.wantValue {
+				t.Errorf("s.Verify() got: %q want: %q", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestSignedCookieRoundTrip(t *testing.T) {
+	s, err := NewCookieSigner(testCookieKey)
+	if err != nil {
+		t.Fatalf("NewCookieSigner() got err: %v", err)
+	}
+	m := NewServeMux(nil)
+	m.Handle("/set", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		c := &http.Cookie{Name: "flash", Value: "Saved", Secure: true, SameSite: http.SameSiteLaxMode}
+		if err := w.Header().SetCookie(s.Sign(c)); err != nil {
+			return w.ServerError(Status500InternalServerError, nil)
+		}
+		return w.NoContent()
+	})
+	var got string
+	var gotErr error
+	m.Handle("/get", "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+		got, gotErr = s.Cookie(r, "flash")
+		return w.NoContent()
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/set", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("cookies got: %v want: 1 cookie", cookies)
+	}
+	if cookies[0].Value == "Saved" {
+		t.Errorf("cookie value got: %q want: a signed value", cookies[0].Value)
+	}
+
+	req := httptest.NewRequest("GET", "/get", nil)
+	req.AddCookie(cookies[0])
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if gotErr != nil || got != "Saved" {
+		t.Errorf(`s.Cookie(r, "flash") got: %q, %v want: "Saved", nil`, got, gotErr)
+	}
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/get", nil))
+	if gotErr != ErrNoCookie {
+		t.Errorf(`s.Cookie(r, "flash") without cookie got err: %v want: %v`, gotErr, ErrNoCookie)
+	}
+}
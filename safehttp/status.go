@@ -20,6 +20,60 @@ type StatusCode int
 const (
 	// Status200OK TODO
 	Status200OK StatusCode = 200
+	// Status204NoContent is returned when the request succeeded and there is
+	// no content to send in the response body.
+	Status204NoContent StatusCode = 204
+	// Status301MovedPermanently is returned when the requested resource has
+	// permanently moved to the URL given by the Location header.
+	Status301MovedPermanently StatusCode = 301
+	// Status303SeeOther is returned to redirect the client to the URL given
+	// by the Location header, which must be retrieved with a GET request,
+	// e.g. after submitting a form.
+	Status303SeeOther StatusCode = 303
+	// Status400BadRequest is returned when the request is malformed or
+	// otherwise rejected by the server.
+	Status400BadRequest StatusCode = 400
+	// Status401Unauthorized is returned when the request lacks valid
+	// authentication credentials.
+	Status401Unauthorized StatusCode = 401
+	// Status403Forbidden is returned when the server understood the request
+	// but refuses to authorize it.
+	Status403Forbidden StatusCode = 403
+	// Status404NotFound is returned when the requested resource doesn't
+	// exist.
+	Status404NotFound StatusCode = 404
+	// Status405MethodNotAllowed is returned when the method of the request
+	// is not supported by the requested resource.
+	Status405MethodNotAllowed StatusCode = 405
+	// Status406NotAcceptable is returned when the server can't produce a
+	// response acceptable according to the Accept-* headers of the request.
+	Status406NotAcceptable StatusCode = 406
+	// Status409Conflict is returned when the request conflicts with the
+	// current state of the target resource.
+	Status409Conflict StatusCode = 409
+	// Status413PayloadTooLarge is returned when the request body is larger
+	// than the server is willing to process.
+	Status413PayloadTooLarge StatusCode = 413
+	// Status415UnsupportedMediaType is returned when the request body is in
+	// a format, or uses a content coding, that the server doesn't support.
+	Status415UnsupportedMediaType StatusCode = 415
+	// Status421MisdirectedRequest is returned when the request was directed
+	// at a server that is not able to produce a response for it.
+	Status421MisdirectedRequest StatusCode = 421
+	// Status426UpgradeRequired is returned when the client must switch to
+	// one of the protocols listed in the Upgrade header.
+	Status426UpgradeRequired StatusCode = 426
+	// Status431RequestHeaderFieldsTooLarge is returned when a header of the
+	// request, or all of them together, are larger than the server is
+	// willing to process.
+	Status431RequestHeaderFieldsTooLarge StatusCode = 431
+	// Status451UnavailableForLegalReasons is returned when the server can't
+	// serve the requested resource for legal reasons.
+	Status451UnavailableForLegalReasons StatusCode = 451
 	// Status500InternalServerError TODO
-	Status500InternalServerError = 500
+	Status500InternalServerError StatusCode = 500
+	// Status503ServiceUnavailable is returned when the server can't handle
+	// the request at the moment, e.g. because it is overloaded or took too
+	// long to handle it.
+	Status503ServiceUnavailable StatusCode = 503
 )
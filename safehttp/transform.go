@@ -0,0 +1,55 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// BodyTransformer rewrites the body of a request, e.g. to convert a legacy
+// format into the canonical one. It returns the new content type and body.
+// Bodies that don't need to be transformed should be returned unchanged.
+type BodyTransformer func(contentType string, body []byte) (newContentType string, newBody []byte, err error)
+
+// WithBodyTransform returns a handler applying t to the body of the request
+// before calling h, so that h only sees the transformed body and content
+// type. It is meant to be used when registering a route.
+//
+// Bodies larger than maxSize bytes are rejected with 413 Payload Too Large,
+// and bodies that t fails to transform with 400 Bad Request.
+func WithBodyTransform(h HandleFunc, maxSize int64, t BodyTransformer) HandleFunc {
+	return func(w ResponseWriter, r *IncomingRequest) Result {
+		// Read one byte more than allowed to detect oversized bodies.
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body(), maxSize+1))
+		if err != nil {
+			return w.ClientError(Status400BadRequest)
+		}
+		if int64(len(body)) > maxSize {
+			return w.ClientError(Status413PayloadTooLarge)
+		}
+		contentType, body, err := t(r.Header.Get("Content-Type"), body)
+		if err != nil {
+			return w.ClientError(Status400BadRequest)
+		}
+		r.Body().Close()
+		r.SetBody(ioutil.NopCloser(bytes.NewReader(body)))
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		return h(w, r)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type order struct {
+	ID       string `xml:"id" json:"id"`
+	Quantity int    `xml:"quantity" json:"quantity"`
+}
+
+func xmlToJSON(contentType string, body []byte) (string, []byte, error) {
+	if contentType != "application/xml" {
+		return contentType, body, nil
+	}
+	var o order
+	if err := xml.Unmarshal(body, &o); err != nil {
+		return "", nil, err
+	}
+	b, err := json.Marshal(o)
+	return "application/json", b, err
+}
+
+func TestWithBodyTransform(t *testing.T) {
+	var tests = []struct {
+		name        string
+		contentType string
+		body        string
+		wantCode    int
+		wantOrder   order
+	}{
+		{
+			name:        "XML",
+			contentType: "application/xml",
+			body:        "<order><id>a1</id><quantity>3</quantity></order>",
+			wantCode:    http.StatusNoContent,
+			wantOrder:   order{ID: "a1", Quantity: 3},
+		},
+		{
+			name:        "JSON",
+			contentType: "application/json",
+			body:        `{"id":"a1","quantity":3}`,
+			wantCode:    http.StatusNoContent,
+			wantOrder:   order{ID: "a1", Quantity: 3},
+		},
+		{
+			name:        "MalformedXML",
+			contentType: "application/xml",
+			body:        "<order>",
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "TooLarge",
+			contentType: "application/xml",
+			body:        "<order><id>" + strings.Repeat("a", 100) + "</id></order>",
+			wantCode:    http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got order
+			h := func(w ResponseWriter, r *IncomingRequest) Result {
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf(`r.Header.Get("Content-Type") got: %q want: "application/json"`, ct)
+				}
+				if err := json.NewDecoder(r.Body()).Decode(&got); err != nil {
+					t.Errorf("json.Decode() got err: %v", err)
+				}
+				return w.NoContent()
+			}
+			m := NewServeMux(nil)
+			m.Handle("/orders", "POST", WithBodyTransform(h, 64, xmlToJSON))
+
+			req := httptest.NewRequest("POST", "/orders", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("rec.Code got: %v want: %v", rec.Code, tt.wantCode)
+			}
+			if got != tt.wantOrder {
+				t.Errorf("decoded order got: %+v want: %+v", got, tt.wantOrder)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JRDContentType is the content type of JSON Resource Descriptors, as served
+// by WebFinger (RFC 7033) and host-meta.json (RFC 6415).
+const JRDContentType = "application/jrd+json"
+
+// DefaultTextMaxAge is the duration for which clients and proxies may cache
+// the files registered with HandleRobotsTXT and HandleSecurityTXT.
+const DefaultTextMaxAge = 24 * time.Hour
+
+// TextFile returns a handler serving content as a text/plain response, which
+// may be cached publicly for maxAge.
+func TextFile(content string, maxAge time.Duration) HandleFunc {
+	cacheControl := fmt.Sprintf("public, max-age=%d", int64(maxAge/time.Second))
+	return func(w ResponseWriter, r *IncomingRequest) Result {
+		if err := w.Header().Set("Cache-Control", cacheControl); err != nil {
+			return w.ServerError(Status500InternalServerError, nil)
+		}
+		return w.writeBody("text/plain; charset=utf-8", []byte(content))
+	}
+}
+
+// HandleRobotsTXT registers a handler serving content at /robots.txt.
+func (m *ServeMux) HandleRobotsTXT(content string) {
+	m.Handle("/robots.txt", "GET", TextFile(content, DefaultTextMaxAge))
+}
+
+// HandleSecurityTXT registers a handler serving content at
+// /.well-known/security.txt, as defined in RFC 9116.
+func (m *ServeMux) HandleSecurityTXT(content string) {
+	m.Handle("/.well-known/security.txt", "GET", TextFile(content, DefaultTextMaxAge))
+}
+
+// HandleWellKnown registers handlers serving the given documents under
+// /.well-known/, keyed by their name, e.g. "webfinger" or "nodeinfo". The
+// documents are encoded as JSON when registering them and served with the
+// JRDContentType for webfinger and host-meta.json, and as application/json
+// otherwise. It panics if a name is empty or contains a slash or a brace, or
+// if a document can't be encoded.
+func (m *ServeMux) HandleWellKnown(docs map[string]interface{}) {
+	for name, doc := range docs {
+		if name == "" || strings.ContainsAny(name, "/{}") {
+			panic(fmt.Sprintf("safehttp: invalid well-known name %q", name))
+		}
+		body, err := json.Marshal(doc)
+		if err != nil {
+			panic(fmt.Sprintf("safehttp: encoding well-known document %q: %v", name, err))
+		}
+		contentType := "application/json; charset=utf-8"
+		if name == "webfinger" || name == "host-meta.json" {
+			contentType = JRDContentType
+		}
+		m.Handle("/.well-known/"+name, "GET", func(w ResponseWriter, r *IncomingRequest) Result {
+			return w.writeBody(contentType, body)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWellKnownTextFiles(t *testing.T) {
+	const (
+		robots   = "User-agent: *\nDisallow: /admin\n"
+		security = "Contact: mailto:security@example.com\n"
+	)
+	m := NewServeMux(nil)
+	m.HandleRobotsTXT(robots)
+	m.HandleSecurityTXT(security)
+
+	var tests = []struct {
+		name     string
+		path     string
+		wantBody string
+	}{
+		{
+			name:     "Robots",
+			path:     "/robots.txt",
+			wantBody: robots,
+		},
+		{
+			name:     "Security",
+			path:     "/.well-known/security.txt",
+			wantBody: security,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+			if got, want := rec.Code, http.StatusOK; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got, want := rec.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+				t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, want)
+			}
+			if got, want := rec.Header().Get("Cache-Control"), "public, max-age=86400"; got != want {
+				t.Errorf(`rec.Header().Get("Cache-Control") got: %q want: %q`, got, want)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandleWellKnown(t *testing.T) {
+	type link struct {
+		Rel  string `json:"rel"`
+		Type string `json:"type,omitempty"`
+		Href string `json:"href"`
+	}
+	m := NewServeMux(nil)
+	m.HandleWellKnown(map[string]interface{}{
+		"webfinger": map[string]interface{}{
+			"subject": "acct:alice@example.com",
+			"links": []link{
+				{Rel: "self", Type: "application/activity+json", Href: "https://example.com/users/alice"},
+			},
+		},
+		"nodeinfo": map[string]interface{}{
+			"links": []link{
+				{Rel: "http://nodeinfo.diaspora.software/ns/schema/2.0", Href: "https://example.com/nodeinfo/2.0"},
+			},
+		},
+	})
+
+	var tests = []struct {
+		name            string
+		path            string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "WebFinger",
+			path:            "/.well-known/webfinger?resource=acct:alice@example.com",
+			wantContentType: "application/jrd+json",
+			wantBody:        `{"links":[{"rel":"self","type":"application/activity+json","href":"https://example.com/users/alice"}],"subject":"acct:alice@example.com"}`,
+		},
+		{
+			name:            "NodeInfo",
+			path:            "/.well-known/nodeinfo",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"links":[{"rel":"http://nodeinfo.diaspora.software/ns/schema/2.0","href":"https://example.com/nodeinfo/2.0"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+			if got, want := rec.Code, http.StatusOK; got != want {
+				t.Errorf("rec.Code got: %v want: %v", got, want)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf(`rec.Header().Get("Content-Type") got: %q want: %q`, got, tt.wantContentType)
+			}
+			if got, want := rec.Header().Get("X-Content-Type-Options"), "nosniff"; got != want {
+				t.Errorf(`rec.Header().Get("X-Content-Type-Options") got: %q want: %q`, got, want)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("rec.Body got: %q want: %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandleWellKnownInvalidName(t *testing.T) {
+	for _, name := range []string{"", "a/b", "{name}"} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("HandleWellKnown(%q) got: no panic want: panic", name)
+				}
+			}()
+			NewServeMux(nil).HandleWellKnown(map[string]interface{}{name: "doc"})
+		})
+	}
+}